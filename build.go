@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/server"
+)
+
+// buildPerPage is the page size requested for list pages, so that a
+// snapshot shows as much as the server allows on one page.
+const buildPerPage = 500
+
+// setupBuild defines the build command, which renders every page of the
+// site to HTML files that can be served by any static file server.
+func setupBuild(fs *flag.FlagSet) func(args []string) error {
+	output := fs.String("o", "site", "Directory to write the site to")
+	logDir := fs.String("log-dir", defaultLogDir(), "Path to Claude Code projects directory")
+	dataDir := fs.String("data-dir", defaultDataDir(), "Path to store comments and other shared data")
+	timezone := fs.String("timezone", "", "IANA time zone to display times in (default: system local)")
+	project := fs.String("project", "", "Only include this project (slug or path)")
+	return func(args []string) error {
+		// Pages are rendered through the server's handler, which logs
+		// every request.
+		slog.SetLogLoggerLevel(slog.LevelWarn)
+		srv, err := newServer(*logDir, *dataDir, *timezone)
+		if err != nil {
+			return err
+		}
+		var slugs []string
+		if *project != "" {
+			slug, err := resolveProject(*logDir, *project)
+			if err != nil {
+				return err
+			}
+			slugs = []string{slug}
+		} else {
+			projects, err := logparser.ListProjects(*logDir)
+			if err != nil {
+				return err
+			}
+			for _, p := range projects {
+				slugs = append(slugs, p.Slug)
+			}
+		}
+
+		b := siteBuilder{handler: srv.Handler(), dir: *output}
+		if *project == "" {
+			b.page("/", true)
+		}
+		for _, slug := range slugs {
+			b.page("/projects/"+slug, true)
+			b.page("/projects/"+slug+"/files", false)
+			sessions, err := srv.Index.ListSessions(*logDir, slug)
+			if err != nil {
+				return err
+			}
+			for _, s := range sessions {
+				b.page("/sessions/"+slug+"/"+s.ID, false)
+				b.file("/sessions/" + slug + "/" + s.ID + "/patch")
+			}
+		}
+		if *project == "" {
+			b.curation(srv)
+		}
+		if err := srv.Index.Save(); err != nil {
+			return err
+		}
+		if b.err != nil {
+			return b.err
+		}
+		fmt.Printf("Wrote %d pages to %s\n", b.pages, *output)
+		return nil
+	}
+}
+
+// siteBuilder requests pages from the server's handler and writes them
+// under dir. The first error stops the build.
+type siteBuilder struct {
+	handler http.Handler
+	dir     string
+	pages   int
+	err     error
+}
+
+// page writes the page at p to p/index.html. List pages are requested with
+// the largest page size.
+func (b *siteBuilder) page(p string, list bool) {
+	query := ""
+	if list {
+		query = fmt.Sprintf("per_page=%d", buildPerPage)
+	}
+	b.write(p, query, path.Join(p, "index.html"))
+}
+
+// file writes the response for p to a file of that name.
+func (b *siteBuilder) file(p string) {
+	b.write(p, "", p)
+}
+
+func (b *siteBuilder) write(p, query, name string) {
+	if b.err != nil {
+		return
+	}
+	u := &url.URL{Path: p, RawQuery: query}
+	rec := httptest.NewRecorder()
+	b.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.String(), nil))
+	if rec.Code == http.StatusNotFound {
+		return
+	}
+	if rec.Code != http.StatusOK {
+		b.err = fmt.Errorf("%s: %d %s", u.Path, rec.Code, http.StatusText(rec.Code))
+		return
+	}
+	dst := filepath.Join(b.dir, filepath.FromSlash(path.Clean("/"+name)))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		b.err = err
+		return
+	}
+	if err := os.WriteFile(dst, rec.Body.Bytes(), 0644); err != nil {
+		b.err = err
+		return
+	}
+	b.pages++
+}
+
+// curation writes the collection and tag pages.
+func (b *siteBuilder) curation(srv *server.Server) {
+	b.page("/collections", false)
+	collections, err := srv.Store.Collections()
+	if err != nil {
+		b.err = err
+		return
+	}
+	for _, c := range collections {
+		b.page("/collections/"+c.ID, false)
+	}
+	tags, err := srv.Store.Tags()
+	if err != nil {
+		b.err = err
+		return
+	}
+	for _, t := range tags {
+		b.page("/tags/"+t.Tag, false)
+	}
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/term"
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// cliFlags are the flags shared by the terminal browsing commands.
+type cliFlags struct {
+	logDir string
+	json   bool
+	color  string
+	pager  bool
+}
+
+func addCLIFlags(fs *flag.FlagSet) *cliFlags {
+	c := &cliFlags{}
+	fs.StringVar(&c.logDir, "log-dir", defaultLogDir(), "Path to Claude Code projects directory")
+	fs.BoolVar(&c.json, "json", false, "Print JSON instead of formatted text")
+	fs.StringVar(&c.color, "color", "auto", "Colourise output: auto, always or never")
+	fs.BoolVar(&c.pager, "pager", true, "Page output through $PAGER when writing to a terminal")
+	return c
+}
+
+// output returns where to write and how to style it. JSON is never paged
+// or coloured.
+func (c *cliFlags) output() (*term.Pager, term.Styler) {
+	if c.json {
+		return term.NewPager(false), term.Styler{}
+	}
+	return term.NewPager(c.pager), term.Styler{Enabled: term.ColorEnabled(c.color, os.Stdout)}
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// setupList defines the list command, which prints the projects.
+func setupList(fs *flag.FlagSet) func(args []string) error {
+	c := addCLIFlags(fs)
+	return func(args []string) error {
+		if len(args) > 0 {
+			fs.Usage()
+			return fmt.Errorf("unexpected argument %q", args[0])
+		}
+
+		projects, err := logparser.ListProjects(c.logDir)
+		if err != nil {
+			return err
+		}
+		out, st := c.output()
+		defer out.Close()
+		if c.json {
+			return writeJSON(out, projects)
+		}
+		for _, p := range projects {
+			fmt.Fprintf(out, "%s\n  %s\n", st.Style(p.Path, term.Bold),
+				st.Style(fmt.Sprintf("%s · %d session%s · last activity %s", p.Slug, p.SessionCount, plural(p.SessionCount), p.LastActivity.Local().Format("2006-01-02 15:04")), term.Faint))
+		}
+		return nil
+	}
+}
+
+// setupSessions defines the sessions command, which prints the sessions
+// of a project, newest first.
+func setupSessions(fs *flag.FlagSet) func(args []string) error {
+	c := addCLIFlags(fs)
+	limit := fs.Int("limit", 0, "Show at most this many sessions")
+	return func(args []string) error {
+		if len(args) != 1 {
+			fs.Usage()
+			return fmt.Errorf("expected one project")
+		}
+
+		slug, err := resolveProject(c.logDir, args[0])
+		if err != nil {
+			return err
+		}
+		sessions, err := logparser.ListSessions(c.logDir, slug)
+		if err != nil {
+			return err
+		}
+		if *limit > 0 && len(sessions) > *limit {
+			sessions = sessions[:*limit]
+		}
+		out, st := c.output()
+		defer out.Close()
+		if c.json {
+			return writeJSON(out, sessions)
+		}
+		for _, s := range sessions {
+			meta := []string{
+				s.Timestamp.Local().Format("2006-01-02 15:04"),
+				fmt.Sprintf("%d message%s", s.MessageCount, plural(s.MessageCount)),
+			}
+			if len(s.Models) > 0 {
+				meta = append(meta, strings.Join(s.Models, ", "))
+			}
+			meta = append(meta, fmt.Sprintf("%d tokens", s.Tokens()), fmt.Sprintf("$%.2f", s.Cost))
+			if n := s.Failures.Total(); n > 0 {
+				meta = append(meta, st.Style(fmt.Sprintf("%d failed", n), term.Red))
+			}
+			first := s.FirstMessage
+			if first == "" {
+				first = "(empty session)"
+			}
+			fmt.Fprintf(out, "%s  %s\n  %s\n", st.Style(s.ID, term.Yellow), st.Style(strings.Join(meta, " · "), term.Faint), first)
+		}
+		return nil
+	}
+}
+
+// setupShow defines the show command, which prints a session as a
+// conversation. Tool calls are collapsed to one line unless --tools is set.
+func setupShow(fs *flag.FlagSet) func(args []string) error {
+	c := addCLIFlags(fs)
+	tools := fs.Bool("tools", false, "Expand tool calls with their input and results")
+	return func(args []string) error {
+		if len(args) != 1 {
+			fs.Usage()
+			return fmt.Errorf("expected one session")
+		}
+
+		slug, sessionID, err := resolveSession(c.logDir, args[0])
+		if err != nil {
+			return err
+		}
+		conv, err := logparser.LoadSession(c.logDir, slug, sessionID)
+		if err != nil {
+			return err
+		}
+		out, st := c.output()
+		defer out.Close()
+		if c.json {
+			return writeJSON(out, conv)
+		}
+		sp := sessionPrinter{w: out, st: st, conv: conv, tools: *tools}
+		sp.print(logparser.ResolveProjectPath(c.logDir, slug).Path)
+		return nil
+	}
+}
+
+// sessionPrinter formats a conversation for the terminal.
+type sessionPrinter struct {
+	w      io.Writer
+	st     term.Styler
+	conv   *logparser.Conversation
+	tools  bool
+	layout string // Time layout of message headers
+}
+
+func (p *sessionPrinter) print(path string) {
+	conv, st := p.conv, p.st
+	tl := logparser.BuildTimeline(conv, 0)
+	p.layout = "15:04"
+	if tl.MultiDay(time.Local) {
+		p.layout = "Jan 2 15:04"
+	}
+
+	fmt.Fprintln(p.w, st.Style(conv.SessionID, term.Bold))
+	var models []string
+	for _, m := range conv.Models {
+		models = append(models, m.Model)
+	}
+	meta := []string{path}
+	if len(models) > 0 {
+		meta = append(meta, strings.Join(models, ", "))
+	}
+	meta = append(meta, fmt.Sprintf("in %d · out %d tokens · $%.2f", conv.TotalInput, conv.TotalOutput, conv.Cost))
+	if !tl.Start.IsZero() {
+		meta = append(meta, fmt.Sprintf("%s – %s", tl.Start.Local().Format("2006-01-02 15:04"), tl.End.Local().Format(p.layout)))
+	}
+	fmt.Fprintln(p.w, st.Style(strings.Join(meta, " · "), term.Faint))
+
+	for _, e := range conv.Entries {
+		switch {
+		case e.Type == "user" && logparser.IsInterrupt(e):
+			fmt.Fprintf(p.w, "\n%s\n", st.Style("[interrupted by user]", term.Red))
+		case e.Type == "user" && e.Message.Content.Text != "":
+			p.header("You", term.Blue, e, "")
+			p.markdown(e.Message.Content.Text)
+		case e.Type == "user":
+			if p.tools {
+				p.toolResults(e)
+			}
+		case e.Type == "assistant":
+			p.assistant(e)
+		case e.Subtype == "compact_boundary":
+			fmt.Fprintf(p.w, "\n%s\n", st.Style("── context compacted ──", term.Faint))
+		}
+	}
+}
+
+func (p *sessionPrinter) header(who, color string, e logparser.LogEntry, note string) {
+	line := p.st.Style(who, term.Bold, color) + "  " + p.st.Style(e.Timestamp.Local().Format(p.layout), term.Faint)
+	if note != "" {
+		line += "  " + p.st.Style(note, term.Magenta)
+	}
+	fmt.Fprintf(p.w, "\n%s\n", line)
+}
+
+func (p *sessionPrinter) markdown(text string) {
+	for _, line := range strings.Split(term.Markdown(text, p.st), "\n") {
+		fmt.Fprintf(p.w, "  %s\n", line)
+	}
+}
+
+func (p *sessionPrinter) assistant(e logparser.LogEntry) {
+	printed := false
+	for _, b := range e.Message.Content.Blocks {
+		text, _ := b.(*claudelog.TextBlock)
+		use, _ := b.(*claudelog.ToolUseBlock)
+		if text == nil && use == nil {
+			continue
+		}
+		if !printed {
+			note := ""
+			if p.conv.ModelChanged(e.UUID) {
+				note = "switched to " + e.Message.Model
+			}
+			p.header("Claude", term.Yellow, e, note)
+			printed = true
+		}
+		if text != nil {
+			p.markdown(text.Text)
+			continue
+		}
+
+		call := p.conv.ToolCalls[use.ID]
+		status := ""
+		if call.Failed() {
+			status = " " + p.st.Style(call.Outcome, term.Red)
+		}
+		if !p.tools {
+			fmt.Fprintf(p.w, "  %s %s%s\n", p.st.Style("▸ "+use.Name, term.Cyan), p.st.Style(toolSummary(logparser.ParseToolInput(use.Input)), term.Faint), status)
+			continue
+		}
+		fmt.Fprintf(p.w, "  %s%s\n", p.st.Style("▾ "+use.Name, term.Cyan), status)
+		var data bytes.Buffer
+		json.Indent(&data, use.Input, "    ", "  ")
+		fmt.Fprintf(p.w, "    %s\n", p.st.Style(data.String(), term.Faint))
+	}
+}
+
+// maxResultLines bounds the tool result lines printed by --tools.
+const maxResultLines = 20
+
+func (p *sessionPrinter) toolResults(e logparser.LogEntry) {
+	for _, b := range e.Message.Content.Blocks {
+		b, ok := b.(*claudelog.ToolResultBlock)
+		if !ok {
+			continue
+		}
+		call := p.conv.ToolCalls[b.ToolUseID]
+		color := term.Faint
+		if call.Failed() {
+			color = term.Red
+		}
+		fmt.Fprintf(p.w, "  %s\n", p.st.Style("← "+call.Name+" result", color))
+		lines := strings.Split(strings.TrimRight(logparser.ResultText(b.Content), "\n"), "\n")
+		extra := len(lines) - maxResultLines
+		if extra > 0 {
+			lines = lines[:maxResultLines]
+		}
+		for _, line := range lines {
+			fmt.Fprintf(p.w, "    %s\n", line)
+		}
+		if extra > 0 {
+			fmt.Fprintf(p.w, "    %s\n", p.st.Style(fmt.Sprintf("… %d more line%s", extra, plural(extra)), term.Faint))
+		}
+	}
+}
+
+// toolSummary picks the most telling input of a tool call for its
+// collapsed one-line form.
+func toolSummary(input logparser.ToolInput) string {
+	for _, s := range []string{input.Command, input.FilePath, input.NotebookPath, input.Pattern, input.Path, input.URL, input.Query, input.Description, input.Prompt} {
+		if s != "" {
+			return truncateLine(s, 100)
+		}
+	}
+	return truncateLine(string(input.Raw), 100)
+}
+
+// setupSearch defines the search command, which searches message text
+// across sessions.
+func setupSearch(fs *flag.FlagSet) func(args []string) error {
+	c := addCLIFlags(fs)
+	ignoreCase := fs.Bool("i", false, "Ignore case")
+	project := fs.String("project", "", "Only search this project (slug or path)")
+	tools := fs.Bool("tools", false, "Also search tool inputs and results")
+	limit := fs.Int("limit", 0, "Stop after this many matches")
+	return func(args []string) error {
+		if len(args) != 1 {
+			fs.Usage()
+			return fmt.Errorf("expected one pattern")
+		}
+
+		pattern := args[0]
+		if *ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		opts := logparser.SearchOptions{Tools: *tools, Limit: *limit}
+		if *project != "" {
+			if opts.Project, err = resolveProject(c.logDir, *project); err != nil {
+				return err
+			}
+		}
+		matches, err := logparser.Search(c.logDir, re, opts)
+		if err != nil {
+			return err
+		}
+
+		out, st := c.output()
+		defer out.Close()
+		if c.json {
+			if matches == nil {
+				matches = []logparser.SearchMatch{}
+			}
+			return writeJSON(out, matches)
+		}
+		session := ""
+		for _, m := range matches {
+			if key := m.Slug + "/" + m.SessionID; key != session {
+				session = key
+				fmt.Fprintf(out, "%s\n", st.Style(key, term.Bold, term.Yellow))
+			}
+			role := "you"
+			if m.Role == "assistant" {
+				role = "claude"
+			}
+			if m.Source != logparser.MatchText {
+				role += " " + m.Source
+			}
+			line, spans := excerpt(m.Line, m.Spans, 160)
+			fmt.Fprintf(out, "  %s %s: %s\n", st.Style(m.Timestamp.Local().Format("2006-01-02 15:04"), term.Faint), st.Style(role, term.Cyan), highlight(line, spans, st))
+		}
+		return nil
+	}
+}
+
+// excerpt cuts line to about width bytes around its first match, shifting
+// spans accordingly.
+func excerpt(line string, spans [][2]int, width int) (string, [][2]int) {
+	if len(line) <= width || len(spans) == 0 {
+		return line, spans
+	}
+	start := max(0, spans[0][0]-width/3)
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	end := min(len(line), start+width)
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(line) {
+		suffix = "…"
+	}
+	var shifted [][2]int
+	for _, s := range spans {
+		if s[0] < start || s[1] > end {
+			continue
+		}
+		shifted = append(shifted, [2]int{s[0] - start + len(prefix), s[1] - start + len(prefix)})
+	}
+	return prefix + line[start:end] + suffix, shifted
+}
+
+// highlight styles the spans of line.
+func highlight(line string, spans [][2]int, st term.Styler) string {
+	var b strings.Builder
+	prev := 0
+	for _, s := range spans {
+		b.WriteString(line[prev:s[0]])
+		b.WriteString(st.Style(line[s[0]:s[1]], term.Bold, term.Red))
+		prev = s[1]
+	}
+	b.WriteString(line[prev:])
+	return b.String()
+}
+
+// resolveProject accepts a project slug or workspace path and returns the
+// slug.
+func resolveProject(logDir, arg string) (string, error) {
+	if fi, err := os.Stat(filepath.Join(logDir, arg)); err == nil && fi.IsDir() && !strings.ContainsRune(arg, filepath.Separator) {
+		return arg, nil
+	}
+	path := arg
+	if abs, err := filepath.Abs(arg); err == nil {
+		path = abs
+	}
+	projects, err := logparser.ListProjects(logDir)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range projects {
+		if p.Path == path || p.Path == arg {
+			return p.Slug, nil
+		}
+	}
+	return "", fmt.Errorf("no project %q in %s", arg, logDir)
+}
+
+// resolveSession splits a "slug/session-id" argument, looking up the
+// project of a bare session ID.
+func resolveSession(logDir, arg string) (slug, sessionID string, err error) {
+	slug, sessionID, found := strings.Cut(arg, "/")
+	if found {
+		return slug, sessionID, nil
+	}
+	sessionID = slug
+	slug, err = logparser.FindSession(logDir, sessionID)
+	return slug, sessionID, err
+}
+
+func truncateLine(s string, max int) string {
+	s, _, _ = strings.Cut(s, "\n")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max]) + "…"
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
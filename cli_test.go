@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nhosoya/claude-code-share/internal/term"
+)
+
+func TestExcerpt(t *testing.T) {
+	line := strings.Repeat("a", 100) + "needle" + strings.Repeat("b", 100)
+	got, spans := excerpt(line, [][2]int{{100, 106}}, 60)
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("excerpt = %q, want ellipses on both sides", got)
+	}
+	if len(spans) != 1 || got[spans[0][0]:spans[0][1]] != "needle" {
+		t.Errorf("spans = %v do not point at the match in %q", spans, got)
+	}
+
+	short, spans := excerpt("a needle", [][2]int{{2, 8}}, 60)
+	if short != "a needle" || spans[0] != [2]int{2, 8} {
+		t.Errorf("short line changed: %q %v", short, spans)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	st := term.Styler{Enabled: true}
+	got := highlight("find a needle here", [][2]int{{7, 13}}, st)
+	want := "find a \x1b[1;31mneedle\x1b[0m here"
+	if got != want {
+		t.Errorf("highlight = %q, want %q", got, want)
+	}
+}
+
+// captureStdout returns what fn writes to standard output.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+	fn()
+	f.Seek(0, io.SeekStart)
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestShowJSON(t *testing.T) {
+	logDir := t.TempDir()
+	os.MkdirAll(filepath.Join(logDir, "-work-app"), 0755)
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T10:00:00Z","sessionId":"sess-1","gitBranch":"main","message":{"role":"user","content":"Hi"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T10:00:05Z","sessionId":"sess-1","message":{"role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"Hello"}],"usage":{"input_tokens":10,"output_tokens":5}}}
+`
+	os.WriteFile(filepath.Join(logDir, "-work-app", "sess-1.jsonl"), []byte(content), 0644)
+
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	run := setupShow(fs)
+	if err := fs.Parse([]string{"--log-dir", logDir, "--json"}); err != nil {
+		t.Fatal(err)
+	}
+	var runErr error
+	out := captureStdout(t, func() { runErr = run([]string{"-work-app/sess-1"}) })
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not a JSON object: %v\n%s", err, out)
+	}
+	// Keys are camelCase like those of list and sessions --json.
+	for _, key := range []string{"sessionId", "entries", "inputTokens", "outputTokens", "cost", "model", "models", "branches", "failures"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("show --json has no %q key: %s", key, out)
+		}
+	}
+	for key := range got {
+		if key[0] < 'a' || key[0] > 'z' {
+			t.Errorf("show --json key %q is not camelCase", key)
+		}
+	}
+}
+
+func TestListRejectsArguments(t *testing.T) {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	run := setupList(fs)
+	if err := run([]string{"extra"}); err == nil {
+		t.Error("list with an argument should fail")
+	}
+}
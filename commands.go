@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/nhosoya/claude-code-share/internal/config"
+)
+
+// command is a subcommand. setup defines its flags and returns the function
+// that runs it with the remaining arguments, once flags are parsed and the
+// config applied.
+type command struct {
+	name    string
+	aliases []string
+	usage   string // Arguments after the command name
+	summary string
+	setup   func(fs *flag.FlagSet) func(args []string) error
+}
+
+// commands returns the subcommands in the order help lists them.
+func commands() []command {
+	return []command{
+		{name: "serve", usage: "[flags]", summary: "Start the web server (the default)", setup: setupServe},
+		{name: "build", usage: "[flags]", summary: "Write a static HTML snapshot of the site", setup: setupBuild},
+		{name: "list", usage: "[flags]", summary: "List projects", setup: setupList},
+		{name: "sessions", usage: "[flags] <project slug or path>", summary: "List the sessions of a project", setup: setupSessions},
+		{name: "show", usage: "[flags] <session-id | slug/session-id>", summary: "Print a session as a conversation", setup: setupShow},
+		{name: "search", aliases: []string{"grep"}, usage: "[flags] <pattern>", summary: "Search message text across sessions", setup: setupSearch},
+		{name: "export", usage: "[flags] <session-id | slug/session-id>", summary: "Export a session, e.g. as a patch", setup: setupExport},
+		{name: "stats", usage: "[flags]", summary: "Print usage totals across all sessions", setup: setupStats},
+		{name: "doctor", usage: "[flags]", summary: "Report malformed and unrecognised log lines", setup: setupDoctor},
+		{name: "index", usage: "[flags]", summary: "Bring the session index up to date", setup: setupIndex},
+		{name: "config", usage: "show [command] [flags]", summary: "Print the effective configuration", setup: setupConfig},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands() {
+		if c.name == name || slices.Contains(c.aliases, name) {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func printCommands(w io.Writer) {
+	fmt.Fprintf(w, "Usage: claude-code-share [command] [flags]\n\nCommands:\n")
+	for _, c := range commands() {
+		name := c.name
+		if len(c.aliases) > 0 {
+			name += " (" + c.aliases[0] + ")"
+		}
+		fmt.Fprintf(w, "  %-16s %s\n", name, c.summary)
+	}
+	fmt.Fprintf(w, "\nRun 'claude-code-share <command> --help' for the flags of a command.\n")
+}
+
+// flags returns the command's flag set, with the --config flag every
+// command accepts, and its run function.
+func (c command) flags() (*flag.FlagSet, *string, func(args []string) error) {
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file (default: $CCS_CONFIG or "+filepath.Join(config.Dir(), "config.{toml,json}")+")")
+	run := c.setup(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: claude-code-share %s %s\n\n", c.name, c.usage)
+		fs.PrintDefaults()
+	}
+	return fs, configPath, run
+}
+
+// parse parses args into fs and fills the flags not given on the command
+// line from the environment and the config file.
+func (c command) parse(fs *flag.FlagSet, configPath *string, args []string) ([]config.Setting, *config.File, error) {
+	fs.Parse(args)
+	path := *configPath
+	if path == "" {
+		path = os.Getenv(config.EnvName("config"))
+	}
+	if path == "" {
+		path = config.Find(config.Dir())
+	}
+	file, err := config.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	settings, err := config.Apply(fs, c.name, file, os.Getenv, "config")
+	return settings, file, err
+}
+
+func (c command) run(args []string) error {
+	fs, configPath, run := c.flags()
+	if _, _, err := c.parse(fs, configPath, args); err != nil {
+		return err
+	}
+	return run(fs.Args())
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindCommand(t *testing.T) {
+	for name, want := range map[string]string{"serve": "serve", "grep": "search", "search": "search"} {
+		c, ok := findCommand(name)
+		if !ok || c.name != want {
+			t.Errorf("findCommand(%q) = %q, %v, want %q", name, c.name, ok, want)
+		}
+	}
+	if _, ok := findCommand("nope"); ok {
+		t.Error("findCommand(nope) found a command")
+	}
+}
+
+func TestCommandParse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := "log-dir = \"/from/file\"\nhost = \"10.0.0.1\"\n[serve]\nport = 4000\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CCS_CONFIG", path)
+	t.Setenv("CCS_HOST", "127.0.0.1")
+
+	c, _ := findCommand("serve")
+	fs, configPath, _ := c.flags()
+	settings, file, err := c.parse(fs, configPath, []string{"--timezone", "UTC", "extra"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Path != path {
+		t.Errorf("file = %q, want %q", file.Path, path)
+	}
+	if fs.NArg() != 1 || fs.Arg(0) != "extra" {
+		t.Errorf("args = %v, want [extra]", fs.Args())
+	}
+
+	want := map[string][2]string{
+		"log-dir":  {"/from/file", "file"},
+		"port":     {"4000", "file"},
+		"host":     {"127.0.0.1", "env"},
+		"timezone": {"UTC", "flag"},
+		"data-dir": {defaultDataDir(), "default"},
+	}
+	for _, s := range settings {
+		w, ok := want[s.Name]
+		if !ok {
+			continue
+		}
+		if s.Value != w[0] || s.Source != w[1] {
+			t.Errorf("%s = %q (%s), want %q (%s)", s.Name, s.Value, s.Source, w[0], w[1])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/nhosoya/claude-code-share/internal/config"
+)
+
+// setupConfig defines the config command. "config show [command] [flags]"
+// prints the settings a command would run with and where each came from.
+func setupConfig(fs *flag.FlagSet) func(args []string) error {
+	asJSON := fs.Bool("json", false, "Print JSON instead of formatted text")
+	return func(args []string) error {
+		if len(args) == 0 || args[0] != "show" {
+			fs.Usage()
+			return fmt.Errorf("expected show")
+		}
+		args = args[1:]
+		name := "serve"
+		if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+			name, args = args[0], args[1:]
+		}
+		cmd, ok := findCommand(name)
+		if !ok {
+			return fmt.Errorf("unknown command %q", name)
+		}
+
+		cfs, configPath, _ := cmd.flags()
+		all, file, err := cmd.parse(cfs, configPath, args)
+		if err != nil {
+			return err
+		}
+		var settings []config.Setting
+		for _, s := range all {
+			if s.Name != "config" {
+				settings = append(settings, s)
+			}
+		}
+		if *asJSON {
+			return writeJSON(os.Stdout, struct {
+				Command  string           `json:"command"`
+				File     string           `json:"file"`
+				Settings []config.Setting `json:"settings"`
+			}{cmd.name, file.Path, settings})
+		}
+
+		path := file.Path
+		if path == "" {
+			path = "none"
+		}
+		fmt.Printf("# %s, config file: %s\n", cmd.name, path)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, s := range settings {
+			fmt.Fprintf(tw, "%s\t= %q\t(%s)\n", s.Name, s.Value, s.Source)
+		}
+		return tw.Flush()
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/term"
+)
+
+// doctorErrors is how many parse errors doctor lists without --all.
+const doctorErrors = 20
+
+// setupDoctor defines the doctor command, which parses every session and
+// summarises parse errors and the entry types and fields the parser
+// ignores. It fails if any line could not be parsed.
+func setupDoctor(fs *flag.FlagSet) func(args []string) error {
+	c := addCLIFlags(fs)
+	project := fs.String("project", "", "Only check this project (slug or path)")
+	all := fs.Bool("all", false, "List every error, not just the first few")
+	return func(args []string) error {
+		slug := ""
+		if *project != "" {
+			var err error
+			if slug, err = resolveProject(c.logDir, *project); err != nil {
+				return err
+			}
+		}
+		report, err := logparser.DiagnoseArchive(context.Background(), c.logDir, slug)
+		if err != nil {
+			return err
+		}
+
+		out, st := c.output()
+		defer out.Close()
+		if c.json {
+			if err := writeJSON(out, report); err != nil {
+				return err
+			}
+		} else {
+			printReport(out, st, report, c.logDir, *all)
+		}
+		if n := report.FilesWithErrors; n > 0 {
+			return fmt.Errorf("%d session file%s with parse errors", n, plural(n))
+		}
+		return nil
+	}
+}
+
+func printReport(out io.Writer, st term.Styler, report *logparser.DiagnosticReport, logDir string, all bool) {
+	status := st.Style("ok", term.Green)
+	if report.FilesWithErrors > 0 {
+		status = st.Style(fmt.Sprintf("%d with errors", report.FilesWithErrors), term.Red)
+	}
+	fmt.Fprintf(out, "%s  %d session file%s · %s\n", st.Style("Checked", term.Bold), report.Files, plural(report.Files), status)
+
+	if len(report.Errors) > 0 {
+		fmt.Fprintf(out, "\n%s\n", st.Style("Errors", term.Bold))
+		errs := report.Errors
+		if !all && len(errs) > doctorErrors {
+			errs = errs[:doctorErrors]
+		}
+		for _, d := range errs {
+			file := d.File
+			if rel, err := filepath.Rel(logDir, d.File); err == nil {
+				file = rel
+			}
+			if d.Line > 0 {
+				file += fmt.Sprintf(":%d", d.Line)
+			}
+			fmt.Fprintf(out, "  %s %s\n    %s\n", st.Style(file, term.Yellow), st.Style(d.Kind, term.Red), d.Error)
+		}
+		if n := len(report.Errors) - len(errs); n > 0 {
+			fmt.Fprintf(out, "  %s\n", st.Style(fmt.Sprintf("… %d more, use --all to list them", n), term.Faint))
+		}
+	}
+	printCounts(out, st, "Unknown entry types", report.UnknownTypes)
+	printCounts(out, st, "Unknown content blocks", report.UnknownBlocks)
+	printCounts(out, st, "Unknown fields", report.UnknownFields)
+}
+
+func printCounts(out io.Writer, st term.Styler, title string, counts []logparser.NameCount) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "\n%s\n", st.Style(title, term.Bold))
+	for _, nc := range counts {
+		fmt.Fprintf(out, "  %-40s %s\n", nc.Name, st.Style(fmt.Sprintf("%d line%s in %d file%s", nc.Lines, plural(nc.Lines), nc.Files, plural(nc.Files)), term.Faint))
+	}
+}
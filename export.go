@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+)
+
+// setupExport defines the export command, which writes a session in a
+// portable format:
+//
+//	claude-code-share export [flags] <session-id | slug/session-id>
+func setupExport(fs *flag.FlagSet) func(args []string) error {
+	logDir := fs.String("log-dir", defaultLogDir(), "Path to Claude Code projects directory")
+	format := fs.String("format", "patch", "Output format: patch")
+	from := fs.String("from", "", "First message to include, as a UUID or 1-based number")
+	to := fs.String("to", "", "Last message to include, as a UUID or 1-based number")
+	output := fs.String("o", "", "Write to this file instead of stdout")
+	return func(args []string) error {
+		if len(args) != 1 {
+			fs.Usage()
+			return fmt.Errorf("expected one session")
+		}
+
+		slug, sessionID, err := resolveSession(*logDir, args[0])
+		if err != nil {
+			return err
+		}
+		conv, err := logparser.LoadSession(*logDir, slug, sessionID)
+		if err != nil {
+			return err
+		}
+
+		var out string
+		switch *format {
+		case "patch":
+			patch, err := logparser.BuildPatch(*logDir, conv, logparser.PatchOptions{From: *from, To: *to})
+			if err != nil {
+				return err
+			}
+			if n := patch.Unanchored(); n > 0 {
+				fmt.Fprintf(os.Stderr, "warning: %d hunk(s) could not be placed deterministically and are marked non-deterministic\n", n)
+			}
+			out = patch.String()
+		default:
+			return fmt.Errorf("unsupported format %q", *format)
+		}
+
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	}
+}
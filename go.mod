@@ -2,4 +2,4 @@ module github.com/nhosoya/claude-code-share
 
 go 1.25.5
 
-require github.com/yuin/goldmark v1.7.16 // indirect
+require github.com/yuin/goldmark v1.7.16
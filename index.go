@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+)
+
+// openIndex opens the session index kept in the data dir. An unreadable
+// index is only a cache, so it is discarded and rebuilt.
+func openIndex(dataDir string) (*logparser.SessionIndex, error) {
+	path := filepath.Join(dataDir, "index.json")
+	ix, err := logparser.OpenIndex(path)
+	if err == nil {
+		return ix, nil
+	}
+	slog.Warn("discarding session index", "error", err)
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("remove index: %w", err)
+	}
+	return logparser.OpenIndex(path)
+}
+
+// setupIndex defines the index command, which brings the session index up
+// to date so that the server and stats start fast.
+func setupIndex(fs *flag.FlagSet) func(args []string) error {
+	logDir := fs.String("log-dir", defaultLogDir(), "Path to Claude Code projects directory")
+	dataDir := fs.String("data-dir", defaultDataDir(), "Path to store comments and other shared data")
+	asJSON := fs.Bool("json", false, "Print JSON instead of formatted text")
+	return func(args []string) error {
+		ix, err := openIndex(*dataDir)
+		if err != nil {
+			return err
+		}
+		stats, err := ix.Update(*logDir)
+		if err != nil {
+			return err
+		}
+		if err := ix.Save(); err != nil {
+			return err
+		}
+		if *asJSON {
+			return writeJSON(os.Stdout, stats)
+		}
+		fmt.Printf("%d projects, %d sessions: %d parsed, %d removed\n", stats.Projects, stats.Sessions, stats.Parsed, stats.Removed)
+		return nil
+	}
+}
@@ -0,0 +1,266 @@
+// Package config layers settings from a config file and environment
+// variables under command-line flags: defaults < file < environment <
+// flags.
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is prepended to flag names to form environment variables, e.g.
+// CCS_LOG_DIR for --log-dir.
+const EnvPrefix = "CCS_"
+
+// Where a setting's value came from.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// File holds the settings read from a config file, keyed by flag name.
+// Settings in a section apply only to the command of that name and are
+// keyed "command.flag".
+type File struct {
+	Path   string
+	Values map[string]string
+}
+
+// Dir returns the directory config files are looked up in:
+// $XDG_CONFIG_HOME/claude-code-share or ~/.config/claude-code-share.
+func Dir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "claude-code-share")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join("~", ".config", "claude-code-share")
+	}
+	return filepath.Join(home, ".config", "claude-code-share")
+}
+
+// Find returns the first of config.toml and config.json that exists in
+// dir, or "" if neither does.
+func Find(dir string) string {
+	for _, name := range []string{"config.toml", "config.json"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load reads a TOML or JSON config file, chosen by extension. An empty path
+// yields an empty File.
+func Load(path string) (*File, error) {
+	f := &File{Path: path, Values: make(map[string]string)}
+	if path == "" {
+		return f, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	switch filepath.Ext(path) {
+	case ".toml":
+		err = parseTOML(string(data), f.Values)
+	case ".json":
+		err = parseJSON(data, f.Values)
+	default:
+		err = fmt.Errorf("unsupported format, want .toml or .json")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// parseTOML reads the subset of TOML the config needs: [sections] and
+// key = value pairs with string, number or boolean values.
+func parseTOML(data string, values map[string]string) error {
+	section := ""
+	sc := bufio.NewScanner(strings.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			name, ok := strings.CutSuffix(stripComment(line), "]")
+			if !ok {
+				return fmt.Errorf("line %d: malformed section %q", n, line)
+			}
+			section = strings.TrimSpace(name[1:])
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected key = value", n)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value, err := tomlValue(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		values[key] = value
+	}
+	return sc.Err()
+}
+
+func tomlValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		end := closingQuote(raw)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		if rest := stripComment(raw[end+1:]); rest != "" {
+			return "", fmt.Errorf("unexpected %q after string", rest)
+		}
+		return strconv.Unquote(raw[:end+1])
+	case strings.HasPrefix(raw, "'"):
+		s, rest, ok := strings.Cut(raw[1:], "'")
+		if !ok {
+			return "", fmt.Errorf("unterminated string")
+		}
+		if rest := stripComment(rest); rest != "" {
+			return "", fmt.Errorf("unexpected %q after string", rest)
+		}
+		return s, nil
+	case strings.HasPrefix(raw, "["), strings.HasPrefix(raw, "{"):
+		return "", fmt.Errorf("arrays and tables are not supported")
+	}
+	v := stripComment(raw)
+	if v == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	return v, nil
+}
+
+// closingQuote returns the index of the quote ending the basic string at
+// the start of s, or -1.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func stripComment(s string) string {
+	s, _, _ = strings.Cut(s, "#")
+	return strings.TrimSpace(s)
+}
+
+// parseJSON reads a JSON object. Nested objects are sections.
+func parseJSON(data []byte, values map[string]string) error {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	var walk func(prefix string, obj map[string]interface{}) error
+	walk = func(prefix string, obj map[string]interface{}) error {
+		for k, v := range obj {
+			key := prefix + k
+			switch v := v.(type) {
+			case string:
+				values[key] = v
+			case bool:
+				values[key] = strconv.FormatBool(v)
+			case float64:
+				values[key] = strconv.FormatFloat(v, 'f', -1, 64)
+			case map[string]interface{}:
+				if prefix != "" {
+					return fmt.Errorf("%s: sections cannot be nested", key)
+				}
+				if err := walk(key+".", v); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("%s: unsupported value %v", key, v)
+			}
+		}
+		return nil
+	}
+	return walk("", obj)
+}
+
+// EnvName returns the environment variable for a flag.
+func EnvName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Setting is the effective value of a flag and where it came from.
+type Setting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Apply sets every flag of fs that was not given on the command line from
+// the environment or, failing that, the file, preferring the command's
+// section over top-level keys. Flags named in skip are left alone. It
+// returns the effective settings in flag order.
+func Apply(fs *flag.FlagSet, command string, file *File, getenv func(string) string, skip ...string) ([]Setting, error) {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	var settings []Setting
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		source := SourceDefault
+		switch {
+		case given[f.Name]:
+			source = SourceFlag
+		case slices.Contains(skip, f.Name):
+		default:
+			value, src, ok := lookup(f.Name, command, file, getenv)
+			if !ok {
+				break
+			}
+			if e := fs.Set(f.Name, value); e != nil {
+				err = fmt.Errorf("%s %s: %w", src, f.Name, e)
+				return
+			}
+			source = src
+		}
+		settings = append(settings, Setting{Name: f.Name, Value: f.Value.String(), Source: source})
+	})
+	return settings, err
+}
+
+func lookup(name, command string, file *File, getenv func(string) string) (value, source string, ok bool) {
+	if v := getenv(EnvName(name)); v != "" {
+		return v, SourceEnv, true
+	}
+	if file == nil {
+		return "", "", false
+	}
+	if v, ok := file.Values[command+"."+name]; ok {
+		return v, SourceFile, true
+	}
+	if v, ok := file.Values[name]; ok {
+		return v, SourceFile, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	toml := `# claude-code-share
+log-dir = "/logs/claude" # shared by all commands
+port = 8080
+open = true
+
+[serve]
+host = '127.0.0.1'
+port = 9000
+`
+	json := `{"log-dir": "/logs/claude", "port": 8080, "open": true, "serve": {"host": "127.0.0.1", "port": 9000}}`
+	for name, content := range map[string]string{"config.toml": toml, "config.json": json} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := Load(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := map[string]string{
+				"log-dir":    "/logs/claude",
+				"port":       "8080",
+				"open":       "true",
+				"serve.host": "127.0.0.1",
+				"serve.port": "9000",
+			}
+			if len(f.Values) != len(want) {
+				t.Errorf("Values = %v, want %v", f.Values, want)
+			}
+			for k, v := range want {
+				if f.Values[k] != v {
+					t.Errorf("Values[%q] = %q, want %q", k, f.Values[k], v)
+				}
+			}
+		})
+	}
+
+	if got := Find(dir); got != filepath.Join(dir, "config.toml") {
+		t.Errorf("Find = %q, want config.toml", got)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"array.toml":  "tags = [\"a\"]\n",
+		"quote.toml":  "host = \"unterminated\n",
+		"nokey.toml":  "just words\n",
+		"nested.json": `{"serve": {"tls": {"cert": "x"}}}`,
+		"config.yaml": "port: 1\n",
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load(%s) should fail", name)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	newFlags := func() (*flag.FlagSet, *string, *int, *string) {
+		fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+		host := fs.String("host", "0.0.0.0", "")
+		port := fs.Int("port", 3333, "")
+		logDir := fs.String("log-dir", "~/.claude/projects", "")
+		return fs, host, port, logDir
+	}
+	file := &File{Values: map[string]string{"port": "8080", "serve.port": "9000", "log-dir": "/from/file", "host": "file-host"}}
+	env := map[string]string{"CCS_HOST": "env-host"}
+
+	fs, host, port, logDir := newFlags()
+	fs.Parse([]string{"--log-dir", "/from/flag"})
+	settings, err := Apply(fs, "serve", file, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *port != 9000 || *host != "env-host" || *logDir != "/from/flag" {
+		t.Errorf("port = %d, host = %q, log-dir = %q; want 9000, env-host, /from/flag", *port, *host, *logDir)
+	}
+	sources := make(map[string]string)
+	for _, s := range settings {
+		sources[s.Name] = s.Source
+	}
+	if sources["port"] != SourceFile || sources["host"] != SourceEnv || sources["log-dir"] != SourceFlag {
+		t.Errorf("sources = %v", sources)
+	}
+
+	// Other commands only see top-level keys.
+	fs, _, port, _ = newFlags()
+	Apply(fs, "export", file, func(string) string { return "" })
+	if *port != 8080 {
+		t.Errorf("export port = %d, want 8080", *port)
+	}
+
+	fs, _, _, _ = newFlags()
+	if _, err := Apply(fs, "serve", &File{Values: map[string]string{"port": "many"}}, func(string) string { return "" }); err == nil {
+		t.Error("invalid value should fail")
+	}
+}
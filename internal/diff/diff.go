@@ -0,0 +1,360 @@
+// Package diff computes line-based unified diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change.
+const DefaultContext = 3
+
+// Kind is the kind of a diff line.
+type Kind byte
+
+const (
+	Equal  Kind = ' '
+	Delete Kind = '-'
+	Insert Kind = '+'
+)
+
+// Line is a single line of a diff, without its trailing newline.
+type Line struct {
+	Kind Kind
+	Text string
+	// NoNewline marks the last line of a file that does not end in a
+	// newline, on the side, or both sides, the line belongs to.
+	NoNewline bool
+}
+
+// noNewlineMarker follows a line lacking its newline in a unified diff.
+const noNewlineMarker = `\ No newline at end of file`
+
+// Hunk is a group of changes with surrounding context.
+type Hunk struct {
+	OldStart, OldLines int // 1-based; OldStart is 0 when OldLines is 0 at the file start
+	NewStart, NewLines int
+	Section            string // Optional text after the @@ range header
+	Lines              []Line
+}
+
+// Header returns the "@@ -a,b +c,d @@" line of the hunk.
+func (h Hunk) Header() string {
+	s := fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+	if h.Section != "" {
+		s += " " + h.Section
+	}
+	return s
+}
+
+func hunkRange(start, n int) string {
+	if n == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+// String formats the hunk, header included.
+func (h Hunk) String() string {
+	var b strings.Builder
+	b.WriteString(h.Header())
+	b.WriteByte('\n')
+	for _, l := range h.Lines {
+		b.WriteByte(byte(l.Kind))
+		b.WriteString(l.Text)
+		b.WriteByte('\n')
+		if l.NoNewline {
+			b.WriteString(noNewlineMarker)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// Added returns the number of inserted lines.
+func (h Hunk) Added() int { return h.count(Insert) }
+
+// Removed returns the number of deleted lines.
+func (h Hunk) Removed() int { return h.count(Delete) }
+
+func (h Hunk) count(k Kind) int {
+	n := 0
+	for _, l := range h.Lines {
+		if l.Kind == k {
+			n++
+		}
+	}
+	return n
+}
+
+// SplitLines splits text into lines without their trailing newlines. A
+// final line without a newline is kept; an empty text has no lines.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// fileLines splits the content of a file into lines for diffing. A last
+// line lacking its newline keeps a "\n" suffix, which no other line can
+// have, so that it only matches a last line lacking one too.
+func fileLines(content string) []string {
+	lines := SplitLines(content)
+	if n := len(lines); n > 0 && !strings.HasSuffix(content, "\n") {
+		lines[n-1] += "\n"
+	}
+	return lines
+}
+
+// markNoNewline turns the suffix added by fileLines into Line.NoNewline.
+func markNoNewline(lines []Line) []Line {
+	for i, l := range lines {
+		if text, ok := strings.CutSuffix(l.Text, "\n"); ok {
+			lines[i].Text, lines[i].NoNewline = text, true
+		}
+	}
+	return lines
+}
+
+// maxEditCost bounds the edit distance searched for between two ranges of
+// lines. Beyond it the ranges are treated as replaced wholesale, which keeps
+// the time for large rewrites near linear at the cost of a minimal diff.
+const maxEditCost = 1000
+
+// Lines returns the line-by-line edit script turning a into b.
+func Lines(a, b []string) []Line {
+	// Compare small integers rather than strings.
+	ids := make(map[string]int)
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, l := range lines {
+			id, ok := ids[l]
+			if !ok {
+				id = len(ids)
+				ids[l] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+	d := differ{a: a, b: b, ai: intern(a), bi: intern(b)}
+	d.out = make([]Line, 0, len(a)+len(b))
+	d.diff(0, len(a), 0, len(b))
+	return d.out
+}
+
+// differ computes an edit script with Myers' linear-space algorithm: it
+// finds the middle snake of the shortest edit path and recurses on either
+// side of it, using memory proportional to the input.
+type differ struct {
+	a, b   []string
+	ai, bi []int
+	out    []Line
+}
+
+// diff appends the edit script turning a[a0:a1] into b[b0:b1].
+func (d *differ) diff(a0, a1, b0, b1 int) {
+	// Strip the common prefix and suffix.
+	for a0 < a1 && b0 < b1 && d.ai[a0] == d.bi[b0] {
+		d.out = append(d.out, Line{Kind: Equal, Text: d.a[a0]})
+		a0++
+		b0++
+	}
+	suf := 0
+	for a0 < a1-suf && b0 < b1-suf && d.ai[a1-1-suf] == d.bi[b1-1-suf] {
+		suf++
+	}
+	a1, b1 = a1-suf, b1-suf
+	defer func() {
+		for _, s := range d.a[a1 : a1+suf] {
+			d.out = append(d.out, Line{Kind: Equal, Text: s})
+		}
+	}()
+
+	if a0 == a1 || b0 == b1 {
+		d.replace(a0, a1, b0, b1)
+		return
+	}
+	x0, y0, x1, y1, ok := d.middleSnake(a0, a1, b0, b1)
+	if !ok {
+		d.replace(a0, a1, b0, b1)
+		return
+	}
+	d.diff(a0, x0, b0, y0)
+	for _, s := range d.a[x0:x1] {
+		d.out = append(d.out, Line{Kind: Equal, Text: s})
+	}
+	d.diff(x1, a1, y1, b1)
+}
+
+// replace appends the deletion of a[a0:a1] and the insertion of b[b0:b1].
+func (d *differ) replace(a0, a1, b0, b1 int) {
+	for _, s := range d.a[a0:a1] {
+		d.out = append(d.out, Line{Kind: Delete, Text: s})
+	}
+	for _, s := range d.b[b0:b1] {
+		d.out = append(d.out, Line{Kind: Insert, Text: s})
+	}
+}
+
+// middleSnake searches forward from the start and backward from the end of
+// the ranges at once until the paths overlap, and returns the snake where
+// they meet as a[x0:x1] matching b[y0:y1]. Both ranges must be non-empty
+// and differ at both ends. It reports false if the edit distance exceeds
+// maxEditCost.
+func (d *differ) middleSnake(a0, a1, b0, b1 int) (x0, y0, x1, y1 int, ok bool) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta&1 != 0
+	rounds := min((n+m+1)/2, maxEditCost)
+	off := rounds + 1
+	vf := make([]int, 2*rounds+3) // Furthest x reached on each diagonal k = x-y
+	vb := make([]int, 2*rounds+3) // Likewise, backward from the end
+
+	for e := 0; e <= rounds; e++ {
+		for k := -e; k <= e; k += 2 {
+			var x int
+			if k == -e || (k != e && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.ai[a0+x] == d.bi[b0+y] {
+				x++
+				y++
+			}
+			vf[off+k] = x
+			if kb := delta - k; odd && kb >= -(e-1) && kb <= e-1 && x+vb[off+kb] >= n {
+				return a0 + sx, b0 + sy, a0 + x, b0 + y, true
+			}
+		}
+		for k := -e; k <= e; k += 2 {
+			var x int
+			if k == -e || (k != e && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.ai[a1-1-x] == d.bi[b1-1-y] {
+				x++
+				y++
+			}
+			vb[off+k] = x
+			if kf := delta - k; !odd && kf >= -e && kf <= e && x+vf[off+kf] >= n {
+				return a1 - x, b1 - y, a1 - sx, b1 - sy, true
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
+
+// Hunks groups the differences between the file contents a and b into hunks
+// with the given number of context lines. A last line that gains or loses
+// its newline counts as changed.
+func Hunks(a, b string, context int) []Hunk {
+	lines := markNoNewline(Lines(fileLines(a), fileLines(b)))
+
+	var hunks []Hunk
+	var cur *Hunk
+	oldLine, newLine := 1, 1
+	lastChange := -1
+	for i, l := range lines {
+		if l.Kind != Equal {
+			if cur == nil || i-lastChange > 2*context {
+				if cur != nil {
+					cur.Lines = append(cur.Lines, lines[lastChange+1:lastChange+1+context]...)
+					hunks = append(hunks, trimHunk(*cur, context))
+				}
+				start := max(0, i-context)
+				cur = &Hunk{
+					OldStart: oldLine - (i - start),
+					NewStart: newLine - (i - start),
+				}
+				cur.Lines = append(cur.Lines, lines[start:i]...)
+			} else {
+				cur.Lines = append(cur.Lines, lines[lastChange+1:i]...)
+			}
+			cur.Lines = append(cur.Lines, l)
+			lastChange = i
+		}
+		if l.Kind != Insert {
+			oldLine++
+		}
+		if l.Kind != Delete {
+			newLine++
+		}
+	}
+	if cur != nil {
+		end := min(len(lines), lastChange+1+context)
+		cur.Lines = append(cur.Lines, lines[lastChange+1:end]...)
+		hunks = append(hunks, trimHunk(*cur, context))
+	}
+	return hunks
+}
+
+// Whole returns a single hunk turning a into b with every line of both,
+// starting at line 1. It is used for changes whose position in the file is
+// unknown.
+func Whole(a, b string) Hunk {
+	h := Hunk{OldStart: 1, NewStart: 1, Lines: Lines(SplitLines(a), SplitLines(b))}
+	return trimHunk(h, len(h.Lines))
+}
+
+// trimHunk drops trailing context beyond the limit and computes the line
+// counts of the hunk.
+func trimHunk(h Hunk, context int) Hunk {
+	last := len(h.Lines) - 1
+	for last >= 0 && h.Lines[last].Kind == Equal {
+		last--
+	}
+	h.Lines = h.Lines[:min(len(h.Lines), last+1+context)]
+	for _, l := range h.Lines {
+		if l.Kind != Insert {
+			h.OldLines++
+		}
+		if l.Kind != Delete {
+			h.NewLines++
+		}
+	}
+	if h.OldLines == 0 {
+		h.OldStart--
+	}
+	if h.NewLines == 0 {
+		h.NewStart--
+	}
+	return h
+}
+
+// File is the diff of a single file.
+type File struct {
+	OldName, NewName string // "/dev/null" for created or deleted files
+	Hunks            []Hunk
+}
+
+// String formats the file diff in unified format.
+func (f File) String() string {
+	if len(f.Hunks) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", f.OldName, f.NewName)
+	for _, h := range f.Hunks {
+		b.WriteString(h.String())
+	}
+	return b.String()
+}
+
+// Unified returns the unified diff between a and b, or "" when they are
+// equal.
+func Unified(oldName, newName, a, b string) string {
+	return File{OldName: oldName, NewName: newName, Hunks: Hunks(a, b, DefaultContext)}.String()
+}
@@ -0,0 +1,207 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	b := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"
+
+	got := Unified("a/x.txt", "b/x.txt", a, b)
+	want := `--- a/x.txt
++++ b/x.txt
+@@ -1,5 +1,5 @@
+ one
+-two
++2
+ three
+ four
+ five
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+`
+	if got != want {
+		t.Errorf("Unified =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnified_CreateAndDelete(t *testing.T) {
+	got := Unified("/dev/null", "b/new.txt", "", "hello\nworld\n")
+	if !strings.Contains(got, "@@ -0,0 +1,2 @@\n+hello\n+world\n") {
+		t.Errorf("create diff = %q", got)
+	}
+	got = Unified("a/old.txt", "/dev/null", "bye\n", "")
+	if !strings.Contains(got, "@@ -1 +0,0 @@\n-bye\n") {
+		t.Errorf("delete diff = %q", got)
+	}
+	if got := Unified("a", "b", "same\n", "same\n"); got != "" {
+		t.Errorf("equal diff = %q, want empty", got)
+	}
+}
+
+func TestUnified_NoNewline(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		marker bool
+	}{
+		{"x\ny", "x\ny\n", true},                          // Newline added
+		{"x\ny\n", "x\ny", true},                          // Newline removed
+		{"x\ny", "x\nz", true},                            // Last line edited, no newline on either side
+		{"", "x", true},                                   // Created without a newline
+		{"1\n2\n3\n4\n5\n6", "one\n2\n3\n4\n5\n6", false}, // Last line out of context
+	}
+	for _, tt := range tests {
+		got := Unified("a/f.txt", "b/f.txt", tt.a, tt.b)
+		if got == "" {
+			t.Errorf("Unified(%q, %q) is empty", tt.a, tt.b)
+			continue
+		}
+		if strings.Contains(got, "\\ No newline at end of file\n") != tt.marker {
+			t.Errorf("Unified(%q, %q) =\n%s\nmarker present = %v", tt.a, tt.b, got, !tt.marker)
+		}
+		gitApplyCheck(t, map[string]string{"f.txt": tt.a}, got)
+	}
+	if got := Unified("a", "b", "x\ny", "x\ny"); got != "" {
+		t.Errorf("equal diff = %q, want empty", got)
+	}
+}
+
+// gitApplyCheck fails the test if git would not apply patch to a tree
+// holding files.
+func gitApplyCheck(t *testing.T, files map[string]string, patch string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("git", "apply", "--check", "-")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(patch)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("git apply --check: %v\n%s\npatch:\n%s", err, out, patch)
+	}
+}
+
+func TestLines(t *testing.T) {
+	a := SplitLines("a\nb\nc\na\nb\nb\na")
+	b := SplitLines("c\nb\na\nb\na\nc")
+	lines := Lines(a, b)
+
+	// Applying the script must reproduce both inputs.
+	var gotA, gotB []string
+	for _, l := range lines {
+		if l.Kind != Insert {
+			gotA = append(gotA, l.Text)
+		}
+		if l.Kind != Delete {
+			gotB = append(gotB, l.Text)
+		}
+	}
+	if strings.Join(gotA, "\n") != strings.Join(a, "\n") || strings.Join(gotB, "\n") != strings.Join(b, "\n") {
+		t.Errorf("script does not reproduce inputs: %v", lines)
+	}
+	changes := 0
+	for _, l := range lines {
+		if l.Kind != Equal {
+			changes++
+		}
+	}
+	if changes != 5 {
+		t.Errorf("changes = %d, want the minimal 5", changes)
+	}
+}
+
+// checkScript reports whether the script turns a into b and returns its
+// number of changed lines.
+func checkScript(t *testing.T, a, b []string, lines []Line) int {
+	t.Helper()
+	var gotA, gotB []string
+	changes := 0
+	for _, l := range lines {
+		if l.Kind != Insert {
+			gotA = append(gotA, l.Text)
+		}
+		if l.Kind != Delete {
+			gotB = append(gotB, l.Text)
+		}
+		if l.Kind != Equal {
+			changes++
+		}
+	}
+	if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+		t.Fatalf("script does not reproduce inputs %q and %q: %v", a, b, lines)
+	}
+	return changes
+}
+
+func TestLines_Minimal(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := func() []string {
+		lines := make([]string, rng.IntN(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.IntN(3)))
+		}
+		return lines
+	}
+	for range 2000 {
+		a, b := random(), random()
+		// The minimal number of changes is len(a)+len(b)-2*LCS.
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		want := len(a) + len(b) - 2*lcs[0][0]
+		if got := checkScript(t, a, b, Lines(a, b)); got != want {
+			t.Fatalf("Lines(%q, %q) has %d changes, want %d", a, b, got, want)
+		}
+	}
+}
+
+func TestLines_LargeRewrite(t *testing.T) {
+	const n = 3000
+	a, b := make([]string, n), make([]string, n)
+	for i := range n {
+		a[i] = fmt.Sprintf("old line %d", i)
+		b[i] = fmt.Sprintf("new line %d", i)
+	}
+	// Keep a few lines in common so the search cannot stop early.
+	for i := 0; i < n; i += 500 {
+		b[i] = a[i]
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	lines := Lines(a, b)
+	runtime.ReadMemStats(&after)
+
+	checkScript(t, a, b, lines)
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 16<<20 {
+		t.Errorf("allocated %d MB for a %d-line rewrite, want well under 16", alloc>>20, n)
+	}
+}
@@ -0,0 +1,149 @@
+package logparser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Size of the archive generated for benchmarks.
+const (
+	benchProjects = 20
+	benchSessions = 25 // Per project
+	benchTurns    = 50 // Per session, each a prompt, a tool call, its result and a reply
+)
+
+// writeBenchArchive generates a log directory of benchProjects projects
+// with benchSessions sessions each, and returns it with the first project.
+func writeBenchArchive(b *testing.B) (logDir, slug string) {
+	b.Helper()
+	logDir = b.TempDir()
+	start := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	for p := range benchProjects {
+		dir := filepath.Join(logDir, fmt.Sprintf("-work-proj%d", p))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.Fatal(err)
+		}
+		for s := range benchSessions {
+			ts := start.Add(time.Duration(p*benchSessions+s) * time.Hour)
+			path := filepath.Join(dir, fmt.Sprintf("sess-%d.jsonl", s))
+			if err := os.WriteFile(path, benchSession(ts), 0644); err != nil {
+				b.Fatal(err)
+			}
+			os.Chtimes(path, ts, ts)
+		}
+	}
+	return logDir, "-work-proj0"
+}
+
+// benchSession returns a session log of benchTurns turns starting at ts.
+func benchSession(ts time.Time) []byte {
+	var buf strings.Builder
+	output := strings.Repeat("some tool output\\n", 50)
+	for i := range benchTurns {
+		t := ts.Add(time.Duration(i) * time.Minute).Format(time.RFC3339)
+		fmt.Fprintf(&buf, `{"type":"user","uuid":"u%d","timestamp":"%s","sessionId":"s","cwd":"/work/app","gitBranch":"main","message":{"role":"user","content":"Please run the tests, step %d"}}`+"\n", i, t, i)
+		fmt.Fprintf(&buf, `{"type":"assistant","uuid":"a%d","timestamp":"%s","sessionId":"s","requestId":"r%d","message":{"id":"m%d","model":"claude-opus-4-6","role":"assistant","content":[{"type":"tool_use","id":"t%d","name":"Bash","input":{"command":"go test ./...","description":"Run tests"}}],"usage":{"input_tokens":10,"output_tokens":50,"cache_creation_input_tokens":100,"cache_read_input_tokens":20000}}}`+"\n", i, t, i, i, i)
+		fmt.Fprintf(&buf, `{"type":"user","uuid":"r%d","timestamp":"%s","sessionId":"s","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t%d","content":"%s"}]}}`+"\n", i, t, i, output)
+		fmt.Fprintf(&buf, `{"type":"assistant","uuid":"b%d","timestamp":"%s","sessionId":"s","requestId":"q%d","message":{"id":"n%d","model":"claude-opus-4-6","role":"assistant","content":[{"type":"text","text":"All tests pass."}],"usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":0,"cache_read_input_tokens":21000}}}`+"\n", i, t, i, i)
+	}
+	return []byte(buf.String())
+}
+
+// benchWorkers runs fn as a sub-benchmark serially and with the default
+// number of workers.
+func benchWorkers(b *testing.B, fn func(b *testing.B)) {
+	defer func(n int) { Workers = n }(Workers)
+	for _, n := range slices.Compact([]int{1, runtime.GOMAXPROCS(0)}) {
+		b.Run(fmt.Sprintf("workers=%d", n), func(b *testing.B) {
+			Workers = n
+			fn(b)
+		})
+	}
+}
+
+func BenchmarkListProjects(b *testing.B) {
+	logDir, _ := writeBenchArchive(b)
+	benchWorkers(b, func(b *testing.B) {
+		for b.Loop() {
+			if _, err := ListProjects(logDir); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkListSessions(b *testing.B) {
+	logDir, slug := writeBenchArchive(b)
+	benchWorkers(b, func(b *testing.B) {
+		for b.Loop() {
+			if _, err := ListSessions(logDir, slug); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkQueryProjectsWithStats(b *testing.B) {
+	logDir, _ := writeBenchArchive(b)
+	benchWorkers(b, func(b *testing.B) {
+		for b.Loop() {
+			if _, err := QueryProjects(context.Background(), logDir, ProjectQuery{WithStats: true}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkIndexListSessions(b *testing.B) {
+	logDir, slug := writeBenchArchive(b)
+	ix, err := OpenIndex("")
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := ix.ListSessions(logDir, slug); err != nil {
+		b.Fatal(err)
+	}
+	for b.Loop() {
+		if _, err := ix.ListSessions(logDir, slug); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseSessionFile(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "s.jsonl")
+	data := benchSession(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		if _, err := ParseSessionFile(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseSession parses with unknown fields reported, as
+// DiagnoseArchive does.
+func BenchmarkParseSession(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "s.jsonl")
+	data := benchSession(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := ParseSession(path, ParseOptions{UnknownFields: true}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package logparser
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// ConversationCache keeps recently loaded conversations in memory, up to a
+// total size estimated from their log files, evicting the least recently
+// used. Entries are checked against the file's size and modification time
+// on every load. When a log has only had lines appended, as while Claude
+// Code is still writing the session, just the new lines are parsed. A nil
+// cache parses the session on every load.
+type ConversationCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // File path -> *cachedConversation
+	lru     *list.List               // Most recently used first
+	bytes   int64
+
+	hits, misses, appends, evictions atomic.Int64
+}
+
+// ConversationCacheStats reports the use of a ConversationCache.
+type ConversationCacheStats struct {
+	CacheStats
+	Appends   int64 `json:"appends"` // Misses that only parsed appended lines
+	Evictions int64 `json:"evictions"`
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"` // Estimated size of the cached conversations
+}
+
+type cachedConversation struct {
+	path    string
+	size    int64 // File size and modification time when loaded
+	modTime time.Time
+	parser  *sessionParser
+	tail    []byte // The last bytes parsed, to tell appends from rewrites
+}
+
+// tailSize is how many bytes before the end of the parsed lines must be
+// unchanged for a longer file to count as appended to.
+const tailSize = 256
+
+// NewConversationCache returns a cache holding the conversations of up to
+// maxBytes of session logs.
+func NewConversationCache(maxBytes int64) *ConversationCache {
+	return &ConversationCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// CacheStats returns the cache's lookup counts and current size.
+func (c *ConversationCache) CacheStats() ConversationCacheStats {
+	if c == nil {
+		return ConversationCacheStats{}
+	}
+	c.mu.Lock()
+	entries, size := len(c.entries), c.bytes
+	c.mu.Unlock()
+	return ConversationCacheStats{
+		CacheStats: CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()},
+		Appends:    c.appends.Load(),
+		Evictions:  c.evictions.Load(),
+		Entries:    entries,
+		Bytes:      size,
+	}
+}
+
+// LoadSession is like the package-level LoadSession but returns the cached
+// conversation if the log has not changed. The conversation is shared
+// between callers and must not be modified.
+func (c *ConversationCache) LoadSession(logDir, slug, sessionID string) (*Conversation, error) {
+	if c == nil {
+		return LoadSession(logDir, slug, sessionID)
+	}
+	sf, err := claudelog.Walker{Root: logDir}.Session(slug, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("open session file: %w", err)
+	}
+	return c.load(sf)
+}
+
+// load returns the cached conversation of a session file, parsing it if
+// it is not cached or has changed.
+func (c *ConversationCache) load(sf claudelog.SessionFile) (*Conversation, error) {
+	if c == nil {
+		return ParseSessionFile(sf.Path)
+	}
+	c.mu.Lock()
+	var prev *cachedConversation
+	if el, ok := c.entries[sf.Path]; ok {
+		prev = el.Value.(*cachedConversation)
+		if prev.size == sf.Size && prev.modTime.Equal(sf.ModTime) {
+			c.lru.MoveToFront(el)
+			c.mu.Unlock()
+			c.hits.Add(1)
+			return prev.parser.conv, nil
+		}
+	}
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	e, err := c.parse(sf, prev)
+	if err != nil {
+		return nil, err
+	}
+	c.add(e)
+	return e.parser.conv, nil
+}
+
+// parse parses the session file, only reading the lines appended since
+// prev if the file has merely grown.
+func (c *ConversationCache) parse(sf claudelog.SessionFile, prev *cachedConversation) (*cachedConversation, error) {
+	f, err := os.Open(sf.Path)
+	if err != nil {
+		return nil, fmt.Errorf("open session file: %w", err)
+	}
+	defer f.Close()
+
+	var p *sessionParser
+	if prev != nil && prev.appendedTo(f, sf.Size) {
+		p = prev.parser.resume()
+		if _, err := f.Seek(p.offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("read session file: %w", err)
+		}
+		c.appends.Add(1)
+	} else {
+		p = newSessionParser(sf.Path, ParseOptions{})
+	}
+	if err := p.parse(f); err != nil {
+		return nil, err
+	}
+	p.finish()
+
+	tail := make([]byte, min(p.offset, tailSize))
+	if _, err := f.ReadAt(tail, p.offset-int64(len(tail))); err != nil {
+		tail = nil
+	}
+	return &cachedConversation{path: sf.Path, size: sf.Size, modTime: sf.ModTime, parser: p, tail: tail}, nil
+}
+
+// appendedTo reports whether f, now size bytes long, is the log e was
+// parsed from with lines appended: it is no shorter than what was parsed,
+// which may be more than e.size if it grew while being parsed, and has the
+// same bytes where the parsed lines ended.
+func (e *cachedConversation) appendedTo(f *os.File, size int64) bool {
+	p := e.parser
+	if p.partial || e.tail == nil || size < p.offset {
+		return false
+	}
+	buf := make([]byte, len(e.tail))
+	_, err := f.ReadAt(buf, p.offset-int64(len(buf)))
+	return err == nil && bytes.Equal(buf, e.tail)
+}
+
+// add caches e, replacing any older entry for its file, and evicts the
+// least recently used entries while the cache is over its size.
+func (c *ConversationCache) add(e *cachedConversation) {
+	size := e.parser.offset
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.path]; ok {
+		old := el.Value.(*cachedConversation)
+		if old.modTime.After(e.modTime) {
+			return // Loaded concurrently from a newer version of the file
+		}
+		c.lru.Remove(el)
+		delete(c.entries, e.path)
+		c.bytes -= old.parser.offset
+	}
+	if size > c.maxBytes {
+		return
+	}
+	c.entries[e.path] = c.lru.PushFront(e)
+	c.bytes += size
+	for c.bytes > c.maxBytes {
+		old := c.lru.Remove(c.lru.Back()).(*cachedConversation)
+		delete(c.entries, old.path)
+		c.bytes -= old.parser.offset
+		c.evictions.Add(1)
+	}
+}
@@ -0,0 +1,167 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+const cacheFixture = `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55Z","sessionId":"s","message":{"role":"user","content":"Run the tests"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:00Z","sessionId":"s","message":{"id":"m1","model":"claude-opus-4-6","role":"assistant","content":[{"type":"text","text":"Running."}],"usage":{"input_tokens":10,"output_tokens":1,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}
+`
+
+// cacheAppend continues cacheFixture: a fragment of the same response,
+// which is merged into a1, and the tool's result.
+const cacheAppend = `{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T06:42:01Z","sessionId":"s","message":{"id":"m1","model":"claude-opus-4-6","role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test"}}],"usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}
+{"type":"user","uuid":"u2","timestamp":"2026-02-25T06:42:05Z","sessionId":"s","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"Exit code 1\nFAIL","is_error":true}]}}
+`
+
+// writeCacheSession writes a session file with the given content and a
+// modification time distinct from any earlier write.
+func writeCacheSession(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, mtime, mtime)
+}
+
+func setupCacheSession(t *testing.T) (logDir, path string) {
+	t.Helper()
+	logDir = t.TempDir()
+	proj := filepath.Join(logDir, "-work-app")
+	os.MkdirAll(proj, 0755)
+	path = filepath.Join(proj, "s.jsonl")
+	writeCacheSession(t, path, cacheFixture, time.Unix(1000, 0))
+	return logDir, path
+}
+
+func TestConversationCache(t *testing.T) {
+	logDir, path := setupCacheSession(t)
+	c := NewConversationCache(1 << 20)
+
+	first, err := c.LoadSession(logDir, "-work-app", "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := c.LoadSession(logDir, "-work-app", "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != first {
+		t.Error("unchanged session was parsed again")
+	}
+
+	// Appended lines are parsed on their own, giving the same result as
+	// parsing the whole file, without changing the earlier conversation.
+	writeCacheSession(t, path, cacheFixture+cacheAppend, time.Unix(2000, 0))
+	grown, err := c.LoadSession(logDir, "-work-app", "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(grown, want) {
+		t.Errorf("after append got %+v,\nwant %+v", grown, want)
+	}
+	if len(first.Entries) != 2 || len(first.Entries[1].Message.Content.Blocks) != 1 || first.Entries[1].Message.Usage.OutputTokens != 1 || first.TotalOutput != 1 {
+		t.Errorf("earlier conversation was modified: %+v", first.Entries)
+	}
+
+	// A rewritten file is parsed in full.
+	rewritten := `{"type":"user","uuid":"x1","timestamp":"2026-02-26T00:00:00Z","sessionId":"s","message":{"role":"user","content":"Something else entirely, at some length to make the file longer than before, so it looks like it could have grown"}}` + "\n"
+	writeCacheSession(t, path, rewritten+rewritten+rewritten+rewritten+rewritten, time.Unix(3000, 0))
+	conv, err := c.LoadSession(logDir, "-work-app", "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conv.Entries) != 5 || conv.Entries[0].UUID != "x1" {
+		t.Errorf("rewritten session entries = %+v", conv.Entries)
+	}
+
+	got := c.CacheStats()
+	if got.Hits != 1 || got.Misses != 3 || got.Appends != 1 || got.Entries != 1 {
+		t.Errorf("CacheStats() = %+v, want 1 hit, 3 misses, 1 append, 1 entry", got)
+	}
+	if info, _ := os.Stat(path); got.Bytes != info.Size() {
+		t.Errorf("Bytes = %d, want the file size %d", got.Bytes, info.Size())
+	}
+}
+
+func TestConversationCache_PartialLine(t *testing.T) {
+	logDir, path := setupCacheSession(t)
+	c := NewConversationCache(1 << 20)
+
+	// A line still being written is not resumed from.
+	full := cacheFixture + cacheAppend
+	writeCacheSession(t, path, full[:len(full)-20], time.Unix(2000, 0))
+	if _, err := c.LoadSession(logDir, "-work-app", "s"); err != nil {
+		t.Fatal(err)
+	}
+	writeCacheSession(t, path, full, time.Unix(3000, 0))
+	conv, err := c.LoadSession(logDir, "-work-app", "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conv.Entries) != 3 || len(conv.Diagnostics) != 0 {
+		t.Errorf("got %d entries and diagnostics %+v, want 3 entries and none", len(conv.Entries), conv.Diagnostics)
+	}
+	if n := c.CacheStats().Appends; n != 0 {
+		t.Errorf("Appends = %d, want 0", n)
+	}
+}
+
+func TestConversationCache_Evicts(t *testing.T) {
+	logDir := t.TempDir()
+	proj := filepath.Join(logDir, "-work-app")
+	os.MkdirAll(proj, 0755)
+	for _, id := range []string{"a", "b", "c"} {
+		writeCacheSession(t, filepath.Join(proj, id+".jsonl"), cacheFixture, time.Unix(1000, 0))
+	}
+
+	// Room for two sessions.
+	c := NewConversationCache(int64(2*len(cacheFixture) + 10))
+	for _, id := range []string{"a", "b", "c", "a"} {
+		if _, err := c.LoadSession(logDir, "-work-app", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := c.CacheStats()
+	if got.Hits != 0 || got.Evictions != 2 || got.Entries != 2 || got.Bytes != int64(2*len(cacheFixture)) {
+		t.Errorf("CacheStats() = %+v, want a evicted by c and reloaded, evicting b", got)
+	}
+}
+
+func TestConversationCache_Concurrent(t *testing.T) {
+	logDir, path := setupCacheSession(t)
+	c := NewConversationCache(1 << 20)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			if i == 4 {
+				f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				f.WriteString(cacheAppend)
+				f.Close()
+			}
+			conv, err := c.LoadSession(logDir, "-work-app", "s")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if n := len(conv.Entries); n != 2 && n != 3 {
+				t.Errorf("got %d entries, want 2 or 3", n)
+			}
+		})
+	}
+	wg.Wait()
+}
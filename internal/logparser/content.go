@@ -0,0 +1,58 @@
+package logparser
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// MessageContent is the content of a message: either a string or a list of
+// claudelog blocks.
+type MessageContent = claudelog.Content
+
+// ResultText returns the text of a tool_result content: the string, or the
+// text blocks of the list joined by newlines.
+func ResultText(c MessageContent) string {
+	if !c.IsBlocks {
+		return c.Text
+	}
+	var parts []string
+	for _, b := range c.Blocks {
+		if t, ok := b.(*claudelog.TextBlock); ok {
+			parts = append(parts, t.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ToolInput is the input of a tool call. Raw holds it as logged; the
+// parameters of Claude Code's built-in tools are also decoded into the
+// fields, which stay empty for tools that do not take them.
+type ToolInput struct {
+	Raw json.RawMessage `json:"-"`
+
+	FilePath     string       `json:"file_path,omitempty"`     // Read, Edit, MultiEdit, Write
+	NotebookPath string       `json:"notebook_path,omitempty"` // NotebookEdit
+	Content      string       `json:"content,omitempty"`       // Write
+	OldString    string       `json:"old_string,omitempty"`    // Edit
+	NewString    string       `json:"new_string,omitempty"`    // Edit
+	ReplaceAll   bool         `json:"replace_all,omitempty"`   // Edit
+	Edits        []StringEdit `json:"edits,omitempty"`         // MultiEdit
+	Command      string       `json:"command,omitempty"`       // Bash
+	Description  string       `json:"description,omitempty"`   // Bash, Task
+	Pattern      string       `json:"pattern,omitempty"`       // Grep, Glob
+	Path         string       `json:"path,omitempty"`          // Grep, Glob, LS
+	URL          string       `json:"url,omitempty"`           // WebFetch
+	Query        string       `json:"query,omitempty"`         // WebSearch
+	Prompt       string       `json:"prompt,omitempty"`        // Task, WebFetch
+}
+
+// ParseToolInput decodes the input of a tool_use block. A parameter of
+// another tool that shares a name but not a type is left out of the
+// fields, as is everything if the input is not an object.
+func ParseToolInput(raw json.RawMessage) ToolInput {
+	in := ToolInput{Raw: raw}
+	json.Unmarshal(raw, &in) // Decoding carries on past type mismatches
+	return in
+}
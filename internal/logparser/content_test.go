@@ -0,0 +1,45 @@
+package logparser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseToolInput(t *testing.T) {
+	in := ParseToolInput(json.RawMessage(`{"file_path":"/a.go","old_string":"x","new_string":"y","replace_all":true}`))
+	if in.FilePath != "/a.go" || in.OldString != "x" || in.NewString != "y" || !in.ReplaceAll {
+		t.Errorf("Edit input = %+v", in)
+	}
+	in = ParseToolInput(json.RawMessage(`{"file_path":"/b.go","edits":[{"old_string":"1","new_string":"2"}]}`))
+	if len(in.Edits) != 1 || in.Edits[0] != (StringEdit{OldString: "1", NewString: "2"}) {
+		t.Errorf("MultiEdit edits = %+v", in.Edits)
+	}
+
+	// A parameter of another type is left out, but kept in Raw.
+	raw := `{"query":{"table":"users"},"path":"/db"}`
+	if in := ParseToolInput(json.RawMessage(raw)); in.Query != "" || in.Path != "/db" || string(in.Raw) != raw {
+		t.Errorf("MCP input = %+v, Raw %s", in, in.Raw)
+	}
+	if in := ParseToolInput(json.RawMessage(`"text"`)); in.FilePath != "" || string(in.Raw) != `"text"` {
+		t.Errorf("string input = %+v", in)
+	}
+}
+
+func TestResultText(t *testing.T) {
+	tests := []struct {
+		content, want string
+	}{
+		{`"plain"`, "plain"},
+		{`[{"type":"text","text":"one"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAAA"}},{"type":"text","text":"two"}]`, "one\ntwo"},
+		{`[]`, ""},
+	}
+	for _, tt := range tests {
+		var c MessageContent
+		if err := json.Unmarshal([]byte(tt.content), &c); err != nil {
+			t.Fatal(err)
+		}
+		if got := ResultText(c); got != tt.want {
+			t.Errorf("ResultText(%s) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
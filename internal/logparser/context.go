@@ -0,0 +1,185 @@
+package logparser
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// Context windows of Claude models, in tokens. The log does not record
+// whether a session ran with the 1M-token window, so it is assumed when the
+// context grows past the default.
+const (
+	DefaultContextWindow = 200_000
+	LargeContextWindow   = 1_000_000
+)
+
+// maxContextGrowth bounds the number of tool results listed in
+// ContextUsage.Growth.
+const maxContextGrowth = 10
+
+// ContextSample is the context size of a single API call.
+type ContextSample struct {
+	UUID      string    `json:"uuid"` // Assistant entry
+	Timestamp time.Time `json:"timestamp"`
+	Tokens    int       `json:"tokens"`
+	Compacted bool      `json:"compacted,omitempty"` // The context was compacted before this call
+}
+
+// Compaction is a point where Claude Code summarized the conversation to
+// free up context.
+type Compaction struct {
+	UUID      string    `json:"uuid"`
+	Timestamp time.Time `json:"timestamp"`
+	Trigger   string    `json:"trigger"`
+	PreTokens int       `json:"preTokens"`
+}
+
+// ContextGrowth is the estimated share of the context taken up by a tool
+// result.
+type ContextGrowth struct {
+	UUID      string `json:"uuid"` // User entry holding the tool result
+	ToolUseID string `json:"toolUseId"`
+	Tool      string `json:"tool"`
+	Tokens    int    `json:"tokens"`
+}
+
+// ContextUsage tracks the size of the context over a session.
+type ContextUsage struct {
+	Window      int             `json:"window"`
+	Peak        int             `json:"peak"`
+	Samples     []ContextSample `json:"samples"`
+	Compactions []Compaction    `json:"compactions,omitempty"`
+	// Growth lists the tool results that grew the context the most,
+	// largest first.
+	Growth []ContextGrowth `json:"growth,omitempty"`
+}
+
+// Percent returns tokens as a percentage of the context window.
+func (c ContextUsage) Percent(tokens int) int {
+	if c.Window == 0 {
+		return 0
+	}
+	return tokens * 100 / c.Window
+}
+
+// BuildContextUsage samples the context size of every assistant call of the
+// main conversation. The growth between two calls, less the output of the
+// first, is what the messages in between added; it is split among the tool
+// results there in proportion to their length. Sub-agent entries and calls
+// to models other than the primary one, such as background requests to a
+// small model, have contexts of their own and are left out.
+func BuildContextUsage(conv *Conversation) ContextUsage {
+	var cu ContextUsage
+	primary := conv.primaryModel()
+	type pendingResult struct {
+		ContextGrowth
+		size int
+	}
+	var (
+		pending    []pendingResult
+		prev       *Usage
+		compacted  bool
+		byToolCall = make(map[string]*ContextGrowth)
+	)
+	for _, e := range conv.Entries {
+		if e.IsSidechain {
+			continue
+		}
+		switch {
+		case e.Type == "system" && e.Subtype == "compact_boundary":
+			c := Compaction{UUID: e.UUID, Timestamp: e.Timestamp}
+			if m := e.CompactMetadata; m != nil {
+				c.Trigger, c.PreTokens = m.Trigger, m.PreTokens
+			}
+			cu.Compactions = append(cu.Compactions, c)
+			compacted = true
+			pending = nil
+			prev = nil
+		case e.Type == "user":
+			for _, b := range e.Message.Content.Blocks {
+				r, ok := b.(*claudelog.ToolResultBlock)
+				if !ok {
+					continue
+				}
+				pending = append(pending, pendingResult{
+					ContextGrowth: ContextGrowth{UUID: e.UUID, ToolUseID: r.ToolUseID, Tool: conv.ToolCalls[r.ToolUseID].Name},
+					size:          len(ResultText(r.Content)),
+				})
+			}
+		case e.Type == "assistant" && e.Message.Usage != nil:
+			if m := e.Message.Model; m != "" && m != primary {
+				continue
+			}
+			u := e.Message.Usage
+			tokens := u.ContextTokens()
+			cu.Samples = append(cu.Samples, ContextSample{UUID: e.UUID, Timestamp: e.Timestamp, Tokens: tokens, Compacted: compacted})
+			cu.Peak = max(cu.Peak, tokens)
+			compacted = false
+
+			if prev != nil && len(pending) > 0 {
+				growth := tokens - prev.ContextTokens() - prev.OutputTokens
+				total := 0
+				for _, p := range pending {
+					total += p.size
+				}
+				for _, p := range pending {
+					share := growth / len(pending)
+					if total > 0 {
+						share = growth * p.size / total
+					}
+					if share <= 0 {
+						continue
+					}
+					if g := byToolCall[p.ToolUseID]; g != nil {
+						g.Tokens += share
+						continue
+					}
+					g := p.ContextGrowth
+					g.Tokens = share
+					byToolCall[p.ToolUseID] = &g
+				}
+			}
+			pending = nil
+			prev = u
+		}
+	}
+
+	cu.Window = DefaultContextWindow
+	if cu.Peak > DefaultContextWindow {
+		cu.Window = LargeContextWindow
+	}
+
+	for _, g := range byToolCall {
+		cu.Growth = append(cu.Growth, *g)
+	}
+	sort.Slice(cu.Growth, func(i, j int) bool {
+		if cu.Growth[i].Tokens != cu.Growth[j].Tokens {
+			return cu.Growth[i].Tokens > cu.Growth[j].Tokens
+		}
+		return cu.Growth[i].ToolUseID < cu.Growth[j].ToolUseID
+	})
+	if len(cu.Growth) > maxContextGrowth {
+		cu.Growth = cu.Growth[:maxContextGrowth]
+	}
+	return cu
+}
+
+// primaryModel returns the model that answered the most messages of the
+// main conversation.
+func (c *Conversation) primaryModel() string {
+	counts := make(map[string]int)
+	primary := ""
+	for _, e := range c.Entries {
+		model := e.Message.Model
+		if e.Type != "assistant" || e.IsSidechain || model == "" || model == syntheticModel {
+			continue
+		}
+		counts[model]++
+		if counts[model] > counts[primary] {
+			primary = model
+		}
+	}
+	return primary
+}
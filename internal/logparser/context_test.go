@@ -0,0 +1,95 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildContextUsage(t *testing.T) {
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T10:00:00Z","sessionId":"sess-1","message":{"role":"user","content":"Look around"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T10:00:05Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{}},{"type":"tool_use","id":"t2","name":"Bash","input":{}}],"usage":{"input_tokens":10,"output_tokens":100,"cache_creation_input_tokens":990,"cache_read_input_tokens":0}}}
+{"type":"user","uuid":"r1","timestamp":"2026-02-25T10:00:06Z","sessionId":"sess-1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"},{"type":"tool_result","tool_use_id":"t2","content":[{"type":"text","text":"yyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyy"}]}]}}
+{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T10:00:10Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"text","text":"Found it"}],"usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":2090,"cache_read_input_tokens":1000}}}
+{"type":"system","subtype":"compact_boundary","uuid":"c1","timestamp":"2026-02-25T10:05:00Z","sessionId":"sess-1","content":"Conversation compacted","compactMetadata":{"trigger":"auto","preTokens":3120}}
+{"type":"user","uuid":"s1","timestamp":"2026-02-25T10:05:00Z","sessionId":"sess-1","isCompactSummary":true,"message":{"role":"user","content":"This session is being continued from a previous conversation."}}
+{"type":"assistant","uuid":"a3","timestamp":"2026-02-25T10:05:10Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"text","text":"Continuing"}],"usage":{"input_tokens":500,"output_tokens":20,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}
+`
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cu := BuildContextUsage(conv)
+	if cu.Window != DefaultContextWindow || cu.Peak != 3100 {
+		t.Errorf("Window = %d, Peak = %d; want %d and 3100", cu.Window, cu.Peak, DefaultContextWindow)
+	}
+	if len(cu.Samples) != 3 || cu.Samples[1].Tokens != 3100 || cu.Samples[1].Compacted || !cu.Samples[2].Compacted {
+		t.Errorf("Samples = %+v", cu.Samples)
+	}
+	if len(cu.Compactions) != 1 || cu.Compactions[0].Trigger != "auto" || cu.Compactions[0].PreTokens != 3120 {
+		t.Errorf("Compactions = %+v", cu.Compactions)
+	}
+
+	// 3100 - 1000 - 100 output tokens = 2000, split 3:1 by result length.
+	want := []ContextGrowth{
+		{UUID: "r1", ToolUseID: "t1", Tool: "Read", Tokens: 1500},
+		{UUID: "r1", ToolUseID: "t2", Tool: "Bash", Tokens: 500},
+	}
+	if len(cu.Growth) != len(want) {
+		t.Fatalf("Growth = %+v, want %+v", cu.Growth, want)
+	}
+	for i := range want {
+		if cu.Growth[i] != want[i] {
+			t.Errorf("Growth[%d] = %+v, want %+v", i, cu.Growth[i], want[i])
+		}
+	}
+	if got := cu.Percent(50_000); got != 25 {
+		t.Errorf("Percent(50000) = %d, want 25", got)
+	}
+}
+
+func TestBuildContextUsage_LargeWindow(t *testing.T) {
+	conv := &Conversation{Entries: []LogEntry{
+		{Type: "assistant", UUID: "a1", Message: &Message{Usage: &Usage{InputTokens: 10, CacheReadInputTokens: 250_000}}},
+	}}
+	if cu := BuildContextUsage(conv); cu.Window != LargeContextWindow {
+		t.Errorf("Window = %d, want %d", cu.Window, LargeContextWindow)
+	}
+}
+
+func TestBuildContextUsage_MainContextOnly(t *testing.T) {
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T10:00:00Z","sessionId":"sess-1","message":{"role":"user","content":"Investigate"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T10:00:05Z","sessionId":"sess-1","message":{"role":"assistant","model":"claude-opus-4-6","content":[{"type":"tool_use","id":"t1","name":"Task","input":{}}],"usage":{"input_tokens":1000,"output_tokens":50}}}
+{"type":"user","uuid":"s1","timestamp":"2026-02-25T10:00:06Z","sessionId":"sess-1","isSidechain":true,"message":{"role":"user","content":"Search the code"}}
+{"type":"assistant","uuid":"s2","timestamp":"2026-02-25T10:00:07Z","sessionId":"sess-1","isSidechain":true,"message":{"role":"assistant","model":"claude-opus-4-6","content":[{"type":"tool_use","id":"t2","name":"Grep","input":{}}],"usage":{"input_tokens":90000,"output_tokens":10}}}
+{"type":"user","uuid":"s3","timestamp":"2026-02-25T10:00:08Z","sessionId":"sess-1","isSidechain":true,"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":"many matches"}]}}
+{"type":"assistant","uuid":"h1","timestamp":"2026-02-25T10:00:09Z","sessionId":"sess-1","message":{"role":"assistant","model":"claude-haiku-4-5","content":[{"type":"text","text":"Title"}],"usage":{"input_tokens":200,"output_tokens":5}}}
+{"type":"user","uuid":"r1","timestamp":"2026-02-25T10:00:10Z","sessionId":"sess-1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"found it"}]}}
+{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T10:00:15Z","sessionId":"sess-1","message":{"role":"assistant","model":"claude-opus-4-6","content":[{"type":"text","text":"Done"}],"usage":{"input_tokens":1250,"output_tokens":20}}}
+`
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cu := BuildContextUsage(conv)
+	if len(cu.Samples) != 2 || cu.Samples[0].UUID != "a1" || cu.Samples[1].UUID != "a2" {
+		t.Errorf("Samples = %+v, want a1 and a2 only", cu.Samples)
+	}
+	if cu.Peak != 1250 {
+		t.Errorf("Peak = %d, want 1250", cu.Peak)
+	}
+	// 1250 - 1000 - 50 output tokens, all from the Task result.
+	if len(cu.Growth) != 1 || cu.Growth[0].ToolUseID != "t1" || cu.Growth[0].Tokens != 200 {
+		t.Errorf("Growth = %+v, want 200 tokens for t1", cu.Growth)
+	}
+}
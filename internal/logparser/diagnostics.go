@@ -0,0 +1,286 @@
+package logparser
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// Kinds of Diagnostic.
+const (
+	DiagMalformedLine = "malformed_line" // The line is not a valid entry and was skipped
+	DiagBadContent    = "bad_content"    // The entry's content blocks could not be decoded
+	DiagUnknownType   = "unknown_type"   // The entry type is not one the parser handles
+	DiagUnknownField  = "unknown_field"  // The entry has fields pkg/claudelog does not model
+	DiagUnknownBlock  = "unknown_block"  // A content block has a type pkg/claudelog does not model
+	DiagUnreadable    = "unreadable"     // The file could not be read at all
+)
+
+// Diagnostic describes a problem found while parsing a session file.
+// Unknown types and fields are reported once per file, at their first
+// occurrence, with Count the number of lines affected.
+type Diagnostic struct {
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`   // 1-based line number
+	Offset int64  `json:"offset,omitempty"` // Byte offset of the line
+	Kind   string `json:"kind"`
+	Error  string `json:"error,omitempty"`
+	Type   string `json:"type,omitempty"`  // Entry type, or block type for DiagUnknownBlock
+	Field  string `json:"field,omitempty"` // Unknown field, as a path such as "message.usage.x"
+	Count  int    `json:"count,omitempty"`
+}
+
+// IsError reports whether data was lost: the diagnostic is not merely
+// about something the parser ignores.
+func (d Diagnostic) IsError() bool {
+	return d.Kind == DiagMalformedLine || d.Kind == DiagBadContent || d.Kind == DiagUnreadable
+}
+
+// knownTypes are the entry types ParseSessionFile handles.
+var knownTypes = map[string]bool{
+	"user": true, "assistant": true, "system": true, "summary": true,
+	"file-history-snapshot": true, "progress": true,
+}
+
+// knownBlocks are the content block types pkg/claudelog models. Blocks of
+// these types only become UnknownBlocks if they cannot be decoded, which is
+// reported as bad content.
+var knownBlocks = map[string]bool{
+	claudelog.TypeText: true, claudelog.TypeThinking: true, claudelog.TypeRedactedThinking: true,
+	claudelog.TypeToolUse: true, claudelog.TypeToolResult: true, claudelog.TypeImage: true,
+	claudelog.TypeDocument: true, claudelog.TypeServerToolUse: true, claudelog.TypeWebSearchToolResult: true,
+}
+
+// diagnoser collects the diagnostics of one file.
+type diagnoser struct {
+	file          string
+	unknownFields bool
+	diags         []Diagnostic
+	seen          map[string]int // Kind, type and field -> index in diags
+}
+
+func newDiagnoser(file string, unknownFields bool) *diagnoser {
+	return &diagnoser{file: file, unknownFields: unknownFields, seen: make(map[string]int)}
+}
+
+func (d *diagnoser) pos(line int, offset int64) Diagnostic {
+	return Diagnostic{File: d.file, Line: line, Offset: offset}
+}
+
+func (d *diagnoser) add(pos Diagnostic, kind string, err error) {
+	pos.Kind = kind
+	pos.Error = err.Error()
+	d.diags = append(d.diags, pos)
+}
+
+// finding is an unknown type, block or field found in an entry.
+type finding struct{ kind, typ, field string }
+
+// entry checks the type of a parsed entry and of its content blocks and,
+// if enabled, the fields of the entry, its message, usage and blocks.
+func (d *diagnoser) entry(pos Diagnostic, e *LogEntry) {
+	if !knownTypes[e.Type] {
+		d.aggregate(pos, DiagUnknownType, e.Type, "")
+		return
+	}
+	var found []finding
+	found = d.fields(found, e.Type, "", e.Extra)
+	if m := e.Message; m != nil {
+		found = d.fields(found, e.Type, "message.", m.Extra)
+		if m.Usage != nil {
+			found = d.fields(found, e.Type, "message.usage.", m.Usage.Extra)
+		}
+		found = d.blocks(found, e.Type, "message.content", m.Content.Blocks)
+	}
+	// Count each finding once per line, however many blocks it is in.
+	for i, f := range found {
+		if !slices.Contains(found[:i], f) {
+			d.aggregate(pos, f.kind, f.typ, f.field)
+		}
+	}
+}
+
+// blocks appends the findings in content blocks, including those nested in
+// tool results. Fields are named after the path to the block, e.g.
+// "message.content[text].citations".
+func (d *diagnoser) blocks(found []finding, typ, path string, blocks []claudelog.Block) []finding {
+	for _, b := range blocks {
+		if _, ok := b.(*claudelog.UnknownBlock); ok {
+			if !knownBlocks[b.BlockType()] {
+				found = append(found, finding{DiagUnknownBlock, b.BlockType(), ""})
+			}
+			continue
+		}
+		blockPath := path + "[" + b.BlockType() + "]"
+		found = d.fields(found, typ, blockPath+".", claudelog.BlockExtra(b))
+		if r, ok := b.(*claudelog.ToolResultBlock); ok {
+			found = d.blocks(found, typ, blockPath+".content", r.Content.Blocks)
+		}
+	}
+	return found
+}
+
+// fields appends the unknown fields in extra, if they are reported.
+func (d *diagnoser) fields(found []finding, typ, prefix string, extra claudelog.Extra) []finding {
+	if !d.unknownFields {
+		return found
+	}
+	for _, name := range slices.Sorted(maps.Keys(extra)) {
+		found = append(found, finding{DiagUnknownField, typ, prefix + name})
+	}
+	return found
+}
+
+func (d *diagnoser) aggregate(pos Diagnostic, kind, typ, field string) {
+	key := kind + "\x00" + typ + "\x00" + field
+	i, ok := d.seen[key]
+	if !ok {
+		pos.Kind, pos.Type, pos.Field = kind, typ, field
+		i = len(d.diags)
+		d.seen[key] = i
+		d.diags = append(d.diags, pos)
+	}
+	d.diags[i].Count++
+}
+
+// DiagnosticReport summarises the parse health of an archive.
+type DiagnosticReport struct {
+	Files           int          `json:"files"`
+	FilesWithErrors int          `json:"filesWithErrors"` // Files with at least one error
+	Errors          []Diagnostic `json:"errors"`          // Errors in every file, in file order
+	// Entry types, content block types and fields the parser ignores, most
+	// frequent first.
+	UnknownTypes  []NameCount `json:"unknownTypes"`
+	UnknownBlocks []NameCount `json:"unknownBlocks"`
+	UnknownFields []NameCount `json:"unknownFields"`
+}
+
+// NameCount is a name with the number of lines and files it occurs in.
+type NameCount struct {
+	Name  string `json:"name"`
+	Lines int    `json:"lines"`
+	Files int    `json:"files"`
+}
+
+// DiagnoseArchive parses every session under logDir, or only those of the
+// project slug if it is not empty, and collects their diagnostics. Files
+// are parsed on Workers goroutines; it gives up once ctx is done.
+func DiagnoseArchive(ctx context.Context, logDir, slug string) (*DiagnosticReport, error) {
+	var ix *SessionIndex
+	return ix.DiagnoseArchive(ctx, logDir, slug)
+}
+
+// DiagnoseArchive is like the package-level DiagnoseArchive but only
+// parses sessions that have changed since they were last diagnosed, and
+// keeps the diagnostics of the others in the index.
+func (ix *SessionIndex) DiagnoseArchive(ctx context.Context, logDir, slug string) (*DiagnosticReport, error) {
+	var slugs []string
+	if slug != "" {
+		slugs = []string{slug}
+	} else {
+		projects, err := ListProjectsContext(ctx, logDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			slugs = append(slugs, p.Slug)
+		}
+	}
+	sort.Strings(slugs)
+
+	var files []claudelog.SessionFile
+	for _, s := range slugs {
+		sf, err := claudelog.Walker{Root: logDir}.Sessions(s)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, sf...)
+	}
+	perFile := make([][]Diagnostic, len(files))
+	if err := forEach(ctx, len(files), func(i int) {
+		perFile[i] = ix.diagnose(files[i])
+	}); err != nil {
+		return nil, err
+	}
+
+	report := &DiagnosticReport{Files: len(files), Errors: []Diagnostic{}}
+	types := make(map[string]*NameCount)
+	blocks := make(map[string]*NameCount)
+	fields := make(map[string]*NameCount)
+	count := func(m map[string]*NameCount, name string, lines int) {
+		nc := m[name]
+		if nc == nil {
+			nc = &NameCount{Name: name}
+			m[name] = nc
+		}
+		nc.Lines += lines
+		nc.Files++
+	}
+	for _, diags := range perFile {
+		failed := false
+		for _, d := range diags {
+			switch {
+			case d.IsError():
+				report.Errors = append(report.Errors, d)
+				failed = true
+			case d.Kind == DiagUnknownType:
+				count(types, d.Type, d.Count)
+			case d.Kind == DiagUnknownBlock:
+				count(blocks, d.Type, d.Count)
+			case d.Kind == DiagUnknownField:
+				count(fields, d.Type+"."+d.Field, d.Count)
+			}
+		}
+		if failed {
+			report.FilesWithErrors++
+		}
+	}
+	report.UnknownTypes = sortedCounts(types)
+	report.UnknownBlocks = sortedCounts(blocks)
+	report.UnknownFields = sortedCounts(fields)
+	return report, nil
+}
+
+// diagnose returns the diagnostics of a session file, from the index if
+// they were recorded for its current size and modification time. Freshly
+// parsed files have their summary and diagnostics indexed together.
+func (ix *SessionIndex) diagnose(f claudelog.SessionFile) []Diagnostic {
+	if ix != nil {
+		ix.mu.Lock()
+		e, ok := ix.entries[f.Path]
+		ix.mu.Unlock()
+		if ok && e.Size == f.Size && e.ModTime.Equal(f.ModTime) && e.Diagnostics != nil {
+			return *e.Diagnostics
+		}
+	}
+	conv, err := ParseSession(f.Path, ParseOptions{UnknownFields: true})
+	if err != nil {
+		return []Diagnostic{{File: f.Path, Kind: DiagUnreadable, Error: err.Error()}}
+	}
+	diags := append([]Diagnostic{}, conv.Diagnostics...)
+	if ix != nil {
+		ix.mu.Lock()
+		ix.entries[f.Path] = indexEntry{Size: f.Size, ModTime: f.ModTime, Session: summarize(f.Project, conv), Diagnostics: &diags}
+		ix.dirty = true
+		ix.mu.Unlock()
+		ix.version.Add(1)
+	}
+	return diags
+}
+
+func sortedCounts(m map[string]*NameCount) []NameCount {
+	out := make([]NameCount, 0, len(m))
+	for _, nc := range m {
+		out = append(out, *nc)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Lines != out[j].Lines {
+			return out[i].Lines > out[j].Lines
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
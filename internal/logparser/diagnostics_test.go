@@ -0,0 +1,191 @@
+package logparser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const diagnosticsFixture = `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55Z","sessionId":"s","message":{"role":"user","content":"hi"}}
+{not json
+{"type":"attachment","uuid":"x1","sessionId":"s"}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:00Z","sessionId":"s","entrypoint":"cli","message":{"role":"assistant","content":[{"type":"text","text":5}]}}
+{"type":"attachment","uuid":"x2","sessionId":"s"}
+{"type":"user","uuid":"u2","timestamp":"2026-02-25T06:43:00Z","sessionId":"s","entrypoint":"cli","message":{"role":"user","content":"bye"}}
+`
+
+// lineOffset returns the byte offset of line n of diagnosticsFixture.
+func lineOffset(n int) int64 {
+	lines := strings.SplitAfter(diagnosticsFixture, "\n")
+	var off int64
+	for _, l := range lines[:n-1] {
+		off += int64(len(l))
+	}
+	return off
+}
+
+func TestParseSessionDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s.jsonl")
+	if err := os.WriteFile(path, []byte(diagnosticsFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conv, err := ParseSession(path, ParseOptions{UnknownFields: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Diagnostic{
+		{File: path, Line: 2, Offset: lineOffset(2), Kind: DiagMalformedLine},
+		{File: path, Line: 3, Offset: lineOffset(3), Kind: DiagUnknownType, Type: "attachment", Count: 2},
+		{File: path, Line: 4, Offset: lineOffset(4), Kind: DiagBadContent},
+		{File: path, Line: 4, Offset: lineOffset(4), Kind: DiagUnknownField, Type: "assistant", Field: "entrypoint", Count: 1},
+		{File: path, Line: 6, Offset: lineOffset(6), Kind: DiagUnknownField, Type: "user", Field: "entrypoint", Count: 1},
+	}
+	if len(conv.Diagnostics) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %+v", len(conv.Diagnostics), len(want), conv.Diagnostics)
+	}
+	for i, w := range want {
+		got := conv.Diagnostics[i]
+		if w.IsError() != (got.Error != "") {
+			t.Errorf("diagnostic %d error = %q", i, got.Error)
+		}
+		got.Error = ""
+		if got != w {
+			t.Errorf("diagnostic %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	// Without the option, fields are not checked.
+	conv, err = ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conv.Diagnostics) != 3 {
+		t.Errorf("got %d diagnostics without UnknownFields, want 3", len(conv.Diagnostics))
+	}
+}
+
+func TestParseSessionNestedDiagnostics(t *testing.T) {
+	content := `{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:00Z","sessionId":"s","message":{"role":"assistant","container":"c1","content":[{"type":"text","text":"a","note":1},{"type":"text","text":"b","note":2},{"type":"mcp_tool_use","id":"m1"}],"usage":{"input_tokens":1,"output_tokens":1,"tier":"x"}}}
+{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:42:01Z","sessionId":"s","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":[{"type":"text","text":"ok","note":3}]},{"type":"mcp_tool_use","id":"m2"}]}}
+`
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv, err := ParseSession(path, ParseOptions{UnknownFields: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type key struct{ kind, typ, field string }
+	got := make(map[key]int)
+	for _, d := range conv.Diagnostics {
+		got[key{d.Kind, d.Type, d.Field}] = d.Count
+	}
+	want := map[key]int{
+		{DiagUnknownField, "assistant", "message.container"}:                          1,
+		{DiagUnknownField, "assistant", "message.usage.tier"}:                         1,
+		{DiagUnknownField, "assistant", "message.content[text].note"}:                 1, // Two blocks on one line
+		{DiagUnknownBlock, "mcp_tool_use", ""}:                                        2,
+		{DiagUnknownField, "user", "message.content[tool_result].content[text].note"}: 1,
+	}
+	if len(got) != len(want) {
+		t.Errorf("diagnostics = %+v, want %d", conv.Diagnostics, len(want))
+	}
+	for k, n := range want {
+		if got[k] != n {
+			t.Errorf("count of %+v = %d, want %d", k, got[k], n)
+		}
+	}
+
+	// Unknown blocks are reported without the option; fields are not.
+	conv, err = ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conv.Diagnostics) != 1 || conv.Diagnostics[0].Kind != DiagUnknownBlock {
+		t.Errorf("diagnostics without UnknownFields = %+v, want the unknown block", conv.Diagnostics)
+	}
+}
+
+func TestDiagnoseArchive(t *testing.T) {
+	dir := t.TempDir()
+	proj := filepath.Join(dir, "-Users-foo-proj")
+	os.MkdirAll(proj, 0755)
+	os.WriteFile(filepath.Join(proj, "bad.jsonl"), []byte(diagnosticsFixture), 0644)
+	writeTestSession(t, proj, "good.jsonl", "2026-02-25T06:41:55Z", "hello")
+	os.WriteFile(filepath.Join(proj, "mcp.jsonl"), []byte(`{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:00Z","sessionId":"mcp","message":{"role":"assistant","content":[{"type":"mcp_tool_use","id":"m1"}]}}
+`), 0644)
+
+	report, err := DiagnoseArchive(context.Background(), dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 3 || report.FilesWithErrors != 1 {
+		t.Errorf("files = %d (%d with errors), want 3 (1)", report.Files, report.FilesWithErrors)
+	}
+	if len(report.Errors) != 2 {
+		t.Errorf("errors = %+v, want the malformed line and the bad content", report.Errors)
+	}
+	if len(report.UnknownTypes) != 1 || report.UnknownTypes[0] != (NameCount{Name: "attachment", Lines: 2, Files: 1}) {
+		t.Errorf("unknown types = %+v", report.UnknownTypes)
+	}
+	if len(report.UnknownFields) != 2 || report.UnknownFields[0].Name != "assistant.entrypoint" {
+		t.Errorf("unknown fields = %+v", report.UnknownFields)
+	}
+	if len(report.UnknownBlocks) != 1 || report.UnknownBlocks[0] != (NameCount{Name: "mcp_tool_use", Lines: 1, Files: 1}) {
+		t.Errorf("unknown blocks = %+v", report.UnknownBlocks)
+	}
+}
+
+func TestSessionIndexDiagnoseArchive(t *testing.T) {
+	dir := t.TempDir()
+	proj := filepath.Join(dir, "-Users-foo-proj")
+	os.MkdirAll(proj, 0755)
+	bad := filepath.Join(proj, "bad.jsonl")
+	os.WriteFile(bad, []byte(diagnosticsFixture), 0644)
+	mtime := time.Unix(1000, 0)
+	os.Chtimes(bad, mtime, mtime)
+
+	ix, err := OpenIndex("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if report, err := ix.DiagnoseArchive(ctx, dir, ""); err != nil || report.FilesWithErrors != 1 {
+		t.Fatalf("DiagnoseArchive = %+v, %v; want 1 file with errors", report, err)
+	}
+	if ix.Len() != 1 {
+		t.Errorf("Len() = %d, want the diagnosed session indexed", ix.Len())
+	}
+
+	// An unchanged file is not parsed again: a rewrite keeping the size and
+	// modification time still reports the old diagnostics.
+	fixed := strings.Replace(diagnosticsFixture, "{not json", `{"a":"b"}`, 1)
+	os.WriteFile(bad, []byte(fixed), 0644)
+	os.Chtimes(bad, mtime, mtime)
+	if report, _ := ix.DiagnoseArchive(ctx, dir, ""); len(report.Errors) != 2 {
+		t.Errorf("unchanged file: errors = %+v, want the indexed two", report.Errors)
+	}
+
+	later := mtime.Add(time.Hour)
+	os.Chtimes(bad, later, later)
+	report, err := ix.DiagnoseArchive(ctx, dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Kind != DiagBadContent {
+		t.Errorf("changed file: errors = %+v, want only the bad content", report.Errors)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := ix.DiagnoseArchive(canceled, dir, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
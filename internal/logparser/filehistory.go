@@ -0,0 +1,196 @@
+package logparser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/internal/diff"
+)
+
+// FileVersion is the content of a file at one checkpoint of a session.
+type FileVersion struct {
+	Version int       `json:"version"` // Backup version; 0 for the reconstructed final state
+	Time    time.Time `json:"time"`
+	// Exists is false when the file did not exist at this point.
+	Exists bool `json:"exists"`
+	// Available is false when the backup file is missing from disk, so the
+	// content is unknown.
+	Available bool   `json:"available"`
+	Content   string `json:"-"`
+	// Diff is the unified diff from the previous available version.
+	Diff string `json:"diff,omitempty"`
+	// Error explains why a reconstructed final state may be incomplete.
+	Error string `json:"error,omitempty"`
+}
+
+// Final reports whether the version is the reconstructed end state.
+func (v FileVersion) Final() bool {
+	return v.Version == 0
+}
+
+// FileHistory is the sequence of versions of one file in a session.
+type FileHistory struct {
+	Path     string        `json:"path"` // Absolute
+	Rel      string        `json:"rel"`  // Relative to the session's working directory
+	Versions []FileVersion `json:"versions"`
+	// NetDiff is the diff from the first to the last available version.
+	NetDiff string `json:"netDiff,omitempty"`
+}
+
+// FileHistoryDir returns the directory holding a session's file backups,
+// which Claude Code keeps next to the projects log directory.
+func FileHistoryDir(logDir, sessionID string) string {
+	return filepath.Join(filepath.Dir(logDir), "file-history", sessionID)
+}
+
+// LoadFileHistory reconstructs the versions of every file recorded in the
+// conversation's file-history snapshots. Each backup holds a file's content
+// at a checkpoint; the final state is rebuilt by replaying the successful
+// edits made after the last checkpoint.
+func LoadFileHistory(logDir string, conv *Conversation) []FileHistory {
+	if len(conv.Snapshots) == 0 {
+		return nil
+	}
+	base := conv.baseCWD()
+	edits := conv.FileEdits()
+	var histories []FileHistory
+	for path, checkpoints := range loadCheckpoints(logDir, conv) {
+		h := FileHistory{Path: path, Rel: relPath(base, path)}
+		for _, cp := range checkpoints {
+			h.Versions = append(h.Versions, cp.FileVersion)
+		}
+		last := checkpoints[len(checkpoints)-1]
+		if final, ok := replayAfter(last, path, edits); ok {
+			h.Versions = append(h.Versions, final)
+		}
+		h.diff()
+		histories = append(histories, h)
+	}
+	sort.Slice(histories, func(i, j int) bool {
+		return histories[i].Rel < histories[j].Rel
+	})
+	return histories
+}
+
+// loadCheckpoints reads the backups recorded in the conversation's
+// snapshots, returning each file's checkpoints in version order.
+func loadCheckpoints(logDir string, conv *Conversation) map[string][]checkpoint {
+	base := conv.baseCWD()
+	dir := FileHistoryDir(logDir, conv.SessionID)
+
+	// Position of each checkpoint's user message, to find the edits that
+	// followed it.
+	msgIndex := make(map[string]int)
+	for i, e := range conv.Entries {
+		msgIndex[e.UUID] = i
+	}
+
+	byPath := make(map[string][]checkpoint)
+	seen := make(map[string]bool)
+	for _, snap := range conv.Snapshots {
+		after, ok := msgIndex[snap.MessageID]
+		if !ok {
+			after = -1
+		}
+		for key, b := range snap.TrackedFileBackups {
+			path := absPath(base, key)
+			id := fmt.Sprintf("%s\x00%d", path, b.Version)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			cp := checkpoint{
+				FileVersion: FileVersion{Version: b.Version, Time: b.BackupTime, Available: true},
+				after:       after,
+				since:       snap.Timestamp,
+			}
+			if b.BackupFileName != "" {
+				data, err := os.ReadFile(filepath.Join(dir, b.BackupFileName))
+				cp.Exists = true
+				cp.Available = err == nil
+				cp.Content = string(data)
+			}
+			byPath[path] = append(byPath[path], cp)
+		}
+	}
+	for _, cps := range byPath {
+		sort.Slice(cps, func(i, j int) bool {
+			return cps[i].Version < cps[j].Version
+		})
+	}
+	return byPath
+}
+
+// checkpoint is a backed-up version together with its position in the log.
+type checkpoint struct {
+	FileVersion
+	after int       // Entries index of the checkpoint message, or -1 if unknown
+	since time.Time // Checkpoint time, used when the message is not in the log
+}
+
+// replayAfter applies the edits of path made after checkpoint v, returning
+// the resulting final version when there were any.
+func replayAfter(v checkpoint, path string, edits []FileEdit) (FileVersion, bool) {
+	final := FileVersion{Exists: v.Exists, Available: v.Available, Content: v.Content}
+	applied := false
+	for _, e := range edits {
+		if e.Path != path || e.Index <= v.after || (v.after < 0 && e.Timestamp.Before(v.since)) {
+			continue
+		}
+		applied = true
+		final.Time = e.Timestamp
+		if !final.Available && !e.IsWrite() {
+			continue
+		}
+		content, err := e.Apply(final.Content)
+		if err != nil {
+			final.Error = fmt.Sprintf("%s at %s: %v", e.Tool, e.Timestamp.Format(time.RFC3339), err)
+			final.Available = false
+			continue
+		}
+		if e.IsWrite() {
+			final.Error = ""
+		}
+		final.Content, final.Exists, final.Available = content, true, final.Error == ""
+	}
+	if !applied || (final.Available && v.Available && final.Content == v.Content) {
+		return FileVersion{}, false
+	}
+	return final, true
+}
+
+// diff fills the diff of every version against the previous available one
+// and the net diff of the history.
+func (h *FileHistory) diff() {
+	var prev, first *FileVersion
+	for i := range h.Versions {
+		v := &h.Versions[i]
+		if !v.Available {
+			continue
+		}
+		if prev != nil {
+			v.Diff = unifiedDiff(h.Rel, *prev, *v)
+		}
+		if first == nil {
+			first = v
+		}
+		prev = v
+	}
+	if first != nil && prev != first {
+		h.NetDiff = unifiedDiff(h.Rel, *first, *prev)
+	}
+}
+
+func unifiedDiff(name string, a, b FileVersion) string {
+	oldName, newName := "a/"+name, "b/"+name
+	if !a.Exists {
+		oldName = "/dev/null"
+	}
+	if !b.Exists {
+		newName = "/dev/null"
+	}
+	return diff.Unified(oldName, newName, a.Content, b.Content)
+}
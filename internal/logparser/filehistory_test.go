@@ -0,0 +1,68 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFileHistory(t *testing.T) {
+	root := t.TempDir()
+	logDir := filepath.Join(root, "projects")
+	projDir := filepath.Join(logDir, "-work-app")
+	backupDir := filepath.Join(root, "file-history", "sess-1")
+	os.MkdirAll(projDir, 0755)
+	os.MkdirAll(backupDir, 0755)
+	os.WriteFile(filepath.Join(backupDir, "aaa@v1"), []byte("hello world\n"), 0644)
+	os.WriteFile(filepath.Join(backupDir, "aaa@v2"), []byte("hi world\n"), 0644)
+	os.WriteFile(filepath.Join(backupDir, "bbb@v2"), []byte("package main\n"), 0644)
+
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T10:00:00Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":"Change things"}}
+{"type":"file-history-snapshot","messageId":"u1","snapshot":{"messageId":"u1","trackedFileBackups":{},"timestamp":"2026-02-25T10:00:00Z"},"isSnapshotUpdate":false}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T10:00:05Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Edit","input":{"file_path":"/work/app/main.go","old_string":"hello","new_string":"hi"}},{"type":"tool_use","id":"t2","name":"Write","input":{"file_path":"/work/app/new.go","content":"package main\n"}}]}}
+{"type":"file-history-snapshot","messageId":"u1","snapshot":{"messageId":"u1","trackedFileBackups":{"/work/app/main.go":{"backupFileName":"aaa@v1","version":1,"backupTime":"2026-02-25T10:00:06Z"},"/work/app/new.go":{"backupFileName":null,"version":1,"backupTime":"2026-02-25T10:00:06Z"}},"timestamp":"2026-02-25T10:00:00Z"},"isSnapshotUpdate":true}
+{"type":"user","uuid":"r1","timestamp":"2026-02-25T10:00:07Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"},{"type":"tool_result","tool_use_id":"t2","content":"ok"}]}}
+{"type":"user","uuid":"u2","timestamp":"2026-02-25T10:01:00Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":"More"}}
+{"type":"file-history-snapshot","messageId":"u2","snapshot":{"messageId":"u2","trackedFileBackups":{"/work/app/main.go":{"backupFileName":"aaa@v2","version":2,"backupTime":"2026-02-25T10:01:00Z"},"/work/app/new.go":{"backupFileName":"bbb@v2","version":2,"backupTime":"2026-02-25T10:01:00Z"}},"timestamp":"2026-02-25T10:01:00Z"},"isSnapshotUpdate":false}
+{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T10:01:05Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"t3","name":"Edit","input":{"file_path":"/work/app/main.go","old_string":"world","new_string":"there"}},{"type":"tool_use","id":"t4","name":"Edit","input":{"file_path":"/work/app/main.go","old_string":"missing","new_string":"x"}}]}}
+{"type":"user","uuid":"r2","timestamp":"2026-02-25T10:01:06Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t3","content":"ok"},{"type":"tool_result","tool_use_id":"t4","content":"String to replace not found","is_error":true}]}}
+`
+	path := filepath.Join(projDir, "sess-1.jsonl")
+	os.WriteFile(path, []byte(content), 0644)
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conv.Snapshots) != 3 {
+		t.Fatalf("Snapshots = %d, want 3", len(conv.Snapshots))
+	}
+
+	histories := LoadFileHistory(logDir, conv)
+	if len(histories) != 2 {
+		t.Fatalf("histories = %+v, want main.go and new.go", histories)
+	}
+
+	main := histories[0]
+	if main.Rel != "main.go" || len(main.Versions) != 3 {
+		t.Fatalf("main.go = %+v, want v1, v2 and final", main)
+	}
+	final := main.Versions[2]
+	if !final.Final() || final.Content != "hi there\n" || final.Error != "" {
+		t.Errorf("final = %+v, want replayed %q", final, "hi there\n")
+	}
+	if !strings.Contains(main.Versions[1].Diff, "-hello world\n+hi world\n") {
+		t.Errorf("v2 diff = %q", main.Versions[1].Diff)
+	}
+	if !strings.Contains(main.NetDiff, "-hello world\n+hi there\n") {
+		t.Errorf("NetDiff = %q", main.NetDiff)
+	}
+
+	created := histories[1]
+	if len(created.Versions) != 2 || created.Versions[0].Exists {
+		t.Errorf("new.go = %+v, want a missing v1 and v2", created)
+	}
+	if !strings.HasPrefix(created.NetDiff, "--- /dev/null\n+++ b/new.go\n") {
+		t.Errorf("new.go NetDiff = %q", created.NetDiff)
+	}
+}
@@ -0,0 +1,276 @@
+package logparser
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// FileOps counts the operations performed on a file.
+type FileOps struct {
+	Read   int `json:"read"`
+	Edit   int `json:"edit"`   // Edit, MultiEdit and NotebookEdit calls
+	Write  int `json:"write"`  // Write calls and mv destinations
+	Delete int `json:"delete"` // rm, git rm and mv sources in Bash commands
+}
+
+// Total returns the number of operations of any kind.
+func (o FileOps) Total() int {
+	return o.Read + o.Edit + o.Write + o.Delete
+}
+
+func (o *FileOps) add(other FileOps) {
+	o.Read += other.Read
+	o.Edit += other.Edit
+	o.Write += other.Write
+	o.Delete += other.Delete
+}
+
+// FileActivity summarizes what a session did to a single file.
+type FileActivity struct {
+	Path string  `json:"path"` // Absolute path
+	Rel  string  `json:"rel"`  // Path relative to the working directory, or Path when outside it
+	Ops  FileOps `json:"ops"`
+	// Created is set when the file was first touched by a write, which
+	// Claude Code only allows for files it has not read, i.e. new files.
+	Created    bool      `json:"created,omitempty"`
+	FirstTouch time.Time `json:"firstTouch"`
+	LastTouch  time.Time `json:"lastTouch"`
+}
+
+// Deleted reports whether the file was removed during the session.
+func (f FileActivity) Deleted() bool {
+	return f.Ops.Delete > 0
+}
+
+// ProjectFile aggregates the activity on a file across a project's sessions.
+type ProjectFile struct {
+	FileActivity
+	Sessions []string `json:"sessions"` // IDs of the sessions that touched the file
+}
+
+// fileOp is a single operation on a path, as extracted from a tool call.
+type fileOp struct {
+	path string
+	ops  FileOps
+}
+
+// SessionFiles lists the files a conversation touched through its tool
+// calls, ordered by relative path. Paths are made relative to the first
+// working directory of the session.
+func SessionFiles(conv *Conversation) []FileActivity {
+	base := conv.baseCWD()
+
+	byPath := make(map[string]*FileActivity)
+	for _, e := range conv.Entries {
+		if e.Type != "assistant" {
+			continue
+		}
+		cwd := e.CWD
+		if cwd == "" {
+			cwd = base
+		}
+		for _, b := range e.Message.Content.Blocks {
+			u, ok := b.(*claudelog.ToolUseBlock)
+			if !ok {
+				continue
+			}
+			for _, op := range toolFileOps(u.Name, ParseToolInput(u.Input)) {
+				path := absPath(cwd, op.path)
+				fa := byPath[path]
+				if fa == nil {
+					fa = &FileActivity{
+						Path:       path,
+						Rel:        relPath(base, path),
+						Created:    op.ops.Write > 0,
+						FirstTouch: e.Timestamp,
+					}
+					byPath[path] = fa
+				}
+				fa.Ops.add(op.ops)
+				fa.LastTouch = e.Timestamp
+			}
+		}
+	}
+
+	files := make([]FileActivity, 0, len(byPath))
+	for _, fa := range byPath {
+		files = append(files, *fa)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Rel < files[j].Rel
+	})
+	return files
+}
+
+// ProjectFiles aggregates the file activity of every session in a project,
+// most touched first. Paths are made relative to the project path.
+func ProjectFiles(logDir, slug string) ([]ProjectFile, error) {
+	return ProjectFilesContext(context.Background(), logDir, slug)
+}
+
+// ProjectFilesContext is ProjectFiles, parsing sessions concurrently and
+// giving up once ctx is done.
+func ProjectFilesContext(ctx context.Context, logDir, slug string) ([]ProjectFile, error) {
+	var c *ConversationCache
+	return c.ProjectFiles(ctx, logDir, slug)
+}
+
+// ProjectFiles is like the package-level ProjectFilesContext but reuses
+// the cached conversations of sessions that have not changed.
+func (c *ConversationCache) ProjectFiles(ctx context.Context, logDir, slug string) ([]ProjectFile, error) {
+	sessionFiles, err := claudelog.Walker{Root: logDir}.Sessions(slug)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	base := ResolveProjectPath(logDir, slug).Path
+
+	activity := make([][]FileActivity, len(sessionFiles))
+	err = forEach(ctx, len(sessionFiles), func(i int) {
+		conv, err := c.load(sessionFiles[i])
+		if err != nil {
+			slog.Warn("skipping session file", "error", err, "file", sessionFiles[i].Path)
+			return
+		}
+		activity[i] = SessionFiles(conv)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]*ProjectFile)
+	for i, sf := range sessionFiles {
+		for _, fa := range activity[i] {
+			pf := byPath[fa.Path]
+			if pf == nil {
+				pf = &ProjectFile{FileActivity: fa}
+				pf.Rel = relPath(base, fa.Path)
+				byPath[fa.Path] = pf
+			} else {
+				pf.Ops.add(fa.Ops)
+				pf.Created = pf.Created || fa.Created
+				if fa.FirstTouch.Before(pf.FirstTouch) {
+					pf.FirstTouch = fa.FirstTouch
+				}
+				if fa.LastTouch.After(pf.LastTouch) {
+					pf.LastTouch = fa.LastTouch
+				}
+			}
+			pf.Sessions = append(pf.Sessions, sf.ID)
+		}
+	}
+
+	files := make([]ProjectFile, 0, len(byPath))
+	for _, pf := range byPath {
+		files = append(files, *pf)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.Ops.Total() != b.Ops.Total() {
+			return a.Ops.Total() > b.Ops.Total()
+		}
+		if len(a.Sessions) != len(b.Sessions) {
+			return len(a.Sessions) > len(b.Sessions)
+		}
+		return a.Rel < b.Rel
+	})
+	return files, nil
+}
+
+// toolFileOps extracts the file operations of a single tool call.
+func toolFileOps(name string, input ToolInput) []fileOp {
+	var op fileOp
+	switch name {
+	case "Read":
+		op = fileOp{input.FilePath, FileOps{Read: 1}}
+	case "Edit", "MultiEdit":
+		op = fileOp{input.FilePath, FileOps{Edit: 1}}
+	case "NotebookEdit":
+		op = fileOp{input.NotebookPath, FileOps{Edit: 1}}
+	case "Write":
+		op = fileOp{input.FilePath, FileOps{Write: 1}}
+	case "Bash":
+		return bashFileOps(input.Command)
+	}
+	if op.path == "" {
+		return nil
+	}
+	return []fileOp{op}
+}
+
+// bashFileOps recognizes file removals and moves in a shell command. Only
+// plain `rm`, `git rm` and `mv` invocations with literal arguments are
+// understood; globs, variables and redirections are ignored.
+func bashFileOps(command string) []fileOp {
+	var ops []fileOp
+	for _, seg := range splitCommands(command) {
+		args := strings.Fields(seg)
+		if len(args) > 0 && args[0] == "git" {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+		var paths []string
+		for _, a := range args[1:] {
+			if strings.HasPrefix(a, "-") {
+				continue
+			}
+			a = strings.Trim(a, `"'`)
+			if a == "" || strings.ContainsAny(a, "*?$`{}<>()") {
+				continue
+			}
+			paths = append(paths, a)
+		}
+
+		switch args[0] {
+		case "rm":
+			for _, p := range paths {
+				ops = append(ops, fileOp{p, FileOps{Delete: 1}})
+			}
+		case "mv":
+			if len(paths) != 2 {
+				continue
+			}
+			ops = append(ops,
+				fileOp{paths[0], FileOps{Delete: 1}},
+				fileOp{paths[1], FileOps{Write: 1}})
+		}
+	}
+	return ops
+}
+
+// splitCommands splits a shell command line on ;, &&, || and | into its
+// simple commands.
+func splitCommands(command string) []string {
+	return strings.FieldsFunc(command, func(r rune) bool {
+		return r == ';' || r == '&' || r == '|' || r == '\n'
+	})
+}
+
+// absPath resolves path against the working directory cwd.
+func absPath(cwd, path string) string {
+	if filepath.IsAbs(path) || cwd == "" {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(cwd, path)
+}
+
+// relPath returns path relative to base, or path itself when it lies
+// outside base.
+func relPath(base, path string) string {
+	if base == "" {
+		return path
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,109 @@
+package logparser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const filesSession = `{"type":"user","uuid":"u1","timestamp":"2026-02-25T10:00:00Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":"Refactor"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T10:01:00Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/work/app/main.go"}},{"type":"tool_use","id":"t2","name":"Edit","input":{"file_path":"/work/app/main.go","old_string":"a","new_string":"b"}}]}}
+{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T10:02:00Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"t3","name":"Write","input":{"file_path":"/work/app/util.go","content":"package main"}},{"type":"tool_use","id":"t4","name":"Bash","input":{"command":"rm -f old.go && mv a.txt docs/a.txt 2>&1 | tail"}},{"type":"tool_use","id":"t5","name":"Read","input":{"file_path":"/etc/hosts"}}]}}
+{"type":"assistant","uuid":"a3","timestamp":"2026-02-25T10:03:00Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"t6","name":"MultiEdit","input":{"file_path":"/work/app/main.go","edits":[]}},{"type":"tool_use","id":"t7","name":"Bash","input":{"command":"rm *.tmp"}}]}}
+`
+
+func TestSessionFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sess-1.jsonl")
+	if err := os.WriteFile(path, []byte(filesSession), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := SessionFiles(conv)
+	byRel := make(map[string]FileActivity)
+	for _, f := range files {
+		byRel[f.Rel] = f
+	}
+	if len(files) != 6 {
+		t.Errorf("files = %v, want 6 entries", files)
+	}
+
+	main := byRel["main.go"]
+	if main.Ops != (FileOps{Read: 1, Edit: 2}) {
+		t.Errorf("main.go ops = %+v, want 1 read and 2 edits", main.Ops)
+	}
+	if main.Created {
+		t.Error("main.go should not be created")
+	}
+	if got := main.LastTouch.Format("15:04"); got != "10:03" {
+		t.Errorf("main.go LastTouch = %q, want %q", got, "10:03")
+	}
+	if !byRel["util.go"].Created {
+		t.Error("util.go should be created")
+	}
+	if !byRel["old.go"].Deleted() || !byRel["a.txt"].Deleted() {
+		t.Error("old.go and a.txt should be deleted")
+	}
+	if byRel["docs/a.txt"].Ops.Write != 1 {
+		t.Errorf("docs/a.txt ops = %+v, want 1 write", byRel["docs/a.txt"].Ops)
+	}
+	if _, ok := byRel["/etc/hosts"]; !ok {
+		t.Error("paths outside cwd should stay absolute")
+	}
+}
+
+func TestProjectFiles(t *testing.T) {
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-work-app")
+	os.MkdirAll(projDir, 0755)
+	os.WriteFile(filepath.Join(projDir, "sess-1.jsonl"), []byte(filesSession), 0644)
+	os.WriteFile(filepath.Join(projDir, "sess-2.jsonl"), []byte(`{"type":"assistant","uuid":"b1","timestamp":"2026-02-26T10:00:00Z","sessionId":"sess-2","cwd":"/work/app/web","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"../main.go"}}]}}
+`), 0644)
+
+	files, err := ProjectFiles(dir, "-work-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 || files[0].Rel != "main.go" {
+		t.Fatalf("files = %v, want main.go first", files)
+	}
+	if files[0].Ops.Total() != 4 || len(files[0].Sessions) != 2 {
+		t.Errorf("main.go = %+v, want 4 ops in 2 sessions", files[0])
+	}
+}
+
+func TestConversationCacheProjectFiles(t *testing.T) {
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-work-app")
+	os.MkdirAll(projDir, 0755)
+	os.WriteFile(filepath.Join(projDir, "sess-1.jsonl"), []byte(filesSession), 0644)
+
+	c := NewConversationCache(1 << 20)
+	for range 2 {
+		files, err := c.ProjectFiles(context.Background(), dir, "-work-app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) == 0 || files[0].Rel != "main.go" || files[0].Sessions[0] != "sess-1" {
+			t.Fatalf("files = %v, want main.go in sess-1 first", files)
+		}
+	}
+	// The session page then reuses the conversation too.
+	if _, err := c.LoadSession(dir, "-work-app", "sess-1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.CacheStats(); got.Hits != 2 || got.Misses != 1 {
+		t.Errorf("CacheStats() = %+v, want 2 hits, 1 miss", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ProjectFiles(ctx, dir, "-work-app"); err != context.Canceled {
+		t.Errorf("canceled ProjectFiles error = %v, want %v", err, context.Canceled)
+	}
+}
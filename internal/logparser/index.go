@@ -0,0 +1,270 @@
+package logparser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// SessionIndex caches session summaries, keyed by file path and
+// invalidated by size and modification time, so that listing sessions does
+// not reparse unchanged files. It can be persisted to disk. A nil index
+// parses every file.
+type SessionIndex struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]indexEntry
+	dirty   bool
+
+	hits, misses atomic.Int64
+	version      atomic.Int64
+}
+
+// CacheStats counts lookups in a cache.
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+type indexEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Session Session   `json:"session"`
+	// Diagnostics are recorded once the file has been checked with
+	// DiagnoseArchive; nil means it has not been.
+	Diagnostics *[]Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// IndexStats reports the work done by SessionIndex.Update.
+type IndexStats struct {
+	Projects int `json:"projects"`
+	Sessions int `json:"sessions"`
+	Parsed   int `json:"parsed"`  // Sessions that were new or changed
+	Removed  int `json:"removed"` // Entries whose file no longer exists
+}
+
+// OpenIndex loads the index stored at path. A missing file yields an empty
+// index that is saved there.
+func OpenIndex(path string) (*SessionIndex, error) {
+	ix := &SessionIndex{path: path, entries: make(map[string]indexEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	if err := json.Unmarshal(data, &ix.entries); err != nil {
+		return nil, fmt.Errorf("parse index %s: %w", path, err)
+	}
+	return ix, nil
+}
+
+// Len returns the number of indexed sessions.
+func (ix *SessionIndex) Len() int {
+	if ix == nil {
+		return 0
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return len(ix.entries)
+}
+
+// CacheStats returns how many session lookups were served from the index
+// and how many had to parse the file.
+func (ix *SessionIndex) CacheStats() CacheStats {
+	if ix == nil {
+		return CacheStats{}
+	}
+	return CacheStats{Hits: ix.hits.Load(), Misses: ix.misses.Load()}
+}
+
+// Version returns a number that increases whenever a summary is added to
+// or removed from the index.
+func (ix *SessionIndex) Version() int64 {
+	if ix == nil {
+		return 0
+	}
+	return ix.version.Load()
+}
+
+// ListSessions is like the package-level ListSessions but only parses
+// sessions that are not in the index or have changed since.
+func (ix *SessionIndex) ListSessions(logDir, slug string) ([]Session, error) {
+	return ix.ListSessionsContext(context.Background(), logDir, slug)
+}
+
+// ListSessionsContext is ListSessions, parsing sessions concurrently and
+// giving up once ctx is done.
+func (ix *SessionIndex) ListSessionsContext(ctx context.Context, logDir, slug string) ([]Session, error) {
+	sessions, _, err := ix.listSessions(ctx, logDir, slug)
+	return sessions, err
+}
+
+// listSessions summarizes the sessions of a project, parsing the files
+// missing from the index on Workers goroutines. It also reports how many
+// were parsed.
+func (ix *SessionIndex) listSessions(ctx context.Context, logDir, slug string) (sessions []Session, parsed int, err error) {
+	files, err := claudelog.Walker{Root: logDir}.Sessions(slug)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list sessions: %w", err)
+	}
+	summaries := make([]*Session, len(files))
+	var parsedCount atomic.Int64
+	err = forEach(ctx, len(files), func(i int) {
+		sess, parsed, err := ix.summary(slug, files[i])
+		if err != nil {
+			slog.Warn("skipping session file", "error", err, "file", files[i].Path)
+			return
+		}
+		summaries[i] = &sess
+		if parsed {
+			parsedCount.Add(1)
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, s := range summaries {
+		if s != nil {
+			sessions = append(sessions, *s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Timestamp.After(sessions[j].Timestamp)
+	})
+	return sessions, int(parsedCount.Load()), nil
+}
+
+// summary returns the summary of a session file from the index, parsing
+// the file if it is not in the index or has changed since. It also reports
+// whether it parsed the file.
+func (ix *SessionIndex) summary(slug string, f claudelog.SessionFile) (Session, bool, error) {
+	if ix != nil {
+		ix.mu.Lock()
+		e, ok := ix.entries[f.Path]
+		ix.mu.Unlock()
+		if ok && e.Size == f.Size && e.ModTime.Equal(f.ModTime) {
+			ix.hits.Add(1)
+			return e.Session, false, nil
+		}
+		ix.misses.Add(1)
+	}
+	conv, err := ParseSessionFile(f.Path)
+	if err != nil {
+		return Session{}, false, err
+	}
+	sess := summarize(slug, conv)
+	if ix != nil {
+		ix.mu.Lock()
+		ix.entries[f.Path] = indexEntry{Size: f.Size, ModTime: f.ModTime, Session: sess}
+		ix.dirty = true
+		ix.mu.Unlock()
+		ix.version.Add(1)
+	}
+	return sess, true, nil
+}
+
+// LoadSessionSummary is like the package-level LoadSessionSummary but
+// only parses the session if it is not in the index or has changed since.
+func (ix *SessionIndex) LoadSessionSummary(logDir, slug, sessionID string) (Session, error) {
+	f, err := claudelog.Walker{Root: logDir}.Session(slug, sessionID)
+	if err != nil {
+		return Session{}, fmt.Errorf("open session file: %w", err)
+	}
+	sess, _, err := ix.summary(slug, f)
+	return sess, err
+}
+
+// QueryProjects is like the package-level QueryProjects but computes
+// project statistics from the index.
+func (ix *SessionIndex) QueryProjects(ctx context.Context, logDir string, q ProjectQuery) ([]Project, error) {
+	return queryProjects(ctx, logDir, q, ix.ListSessionsContext)
+}
+
+// QuerySessions is like the package-level QuerySessions but reads the
+// sessions from the index.
+func (ix *SessionIndex) QuerySessions(ctx context.Context, logDir, slug string, q SessionQuery) ([]Session, error) {
+	sessions, err := ix.ListSessionsContext(ctx, logDir, slug)
+	if err != nil {
+		return nil, err
+	}
+	return FilterSessions(sessions, q), nil
+}
+
+// Update brings the index up to date with every session under logDir and
+// drops entries for files that no longer exist.
+func (ix *SessionIndex) Update(logDir string) (IndexStats, error) {
+	var st IndexStats
+	projects, err := ListProjects(logDir)
+	if err != nil {
+		return st, err
+	}
+	seen := make(map[string]bool)
+	for _, p := range projects {
+		sessions, parsed, err := ix.listSessions(context.Background(), logDir, p.Slug)
+		if err != nil {
+			return st, err
+		}
+		for _, s := range sessions {
+			seen[filepath.Join(logDir, p.Slug, s.ID+".jsonl")] = true
+		}
+		st.Projects++
+		st.Sessions += len(sessions)
+		st.Parsed += parsed
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for path := range ix.entries {
+		if !seen[path] {
+			delete(ix.entries, path)
+			ix.dirty = true
+			ix.version.Add(1)
+			st.Removed++
+		}
+	}
+	return st, nil
+}
+
+// Save writes the index to disk if it changed since it was opened or last
+// saved.
+func (ix *SessionIndex) Save() error {
+	if ix == nil || ix.path == "" {
+		return nil
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if !ix.dirty {
+		return nil
+	}
+	data, err := json.Marshal(ix.entries)
+	if err != nil {
+		return fmt.Errorf("encode index: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0755); err != nil {
+		return fmt.Errorf("create index dir: %w", err)
+	}
+	tmp := ix.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	if err := os.Rename(tmp, ix.path); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	ix.dirty = false
+	return nil
+}
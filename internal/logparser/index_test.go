@@ -0,0 +1,113 @@
+package logparser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSessionIndex(t *testing.T) {
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "projects")
+	proj := filepath.Join(logDir, "-work-app")
+	os.MkdirAll(proj, 0755)
+	writeTestSession(t, proj, "sess-a.jsonl", "2026-02-24T10:00:00.000Z", "First")
+	writeTestSession(t, proj, "sess-b.jsonl", "2026-02-25T10:00:00.000Z", "Second")
+
+	indexPath := filepath.Join(dir, "data", "index.json")
+	ix, err := OpenIndex(indexPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	st, err := ix.Update(logDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st != (IndexStats{Projects: 1, Sessions: 2, Parsed: 2}) {
+		t.Errorf("first Update = %+v", st)
+	}
+	if err := ix.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reopened index only reparses changed files.
+	ix, err = OpenIndex(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestSession(t, proj, "sess-b.jsonl", "2026-02-25T11:00:00.000Z", "Second, edited")
+	if st, _ := ix.Update(logDir); st.Parsed != 1 || ix.Len() != 2 {
+		t.Errorf("after edit: Update = %+v, Len = %d; want 1 parsed of 2", st, ix.Len())
+	}
+	sessions, err := ix.ListSessions(logDir, "-work-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].FirstMessage != "Second, edited" {
+		t.Errorf("sessions = %+v, want the edited session first", sessions)
+	}
+	if v := ix.Version(); v != 1 {
+		t.Errorf("Version() = %d after one reparse, want 1", v)
+	}
+
+	os.Remove(filepath.Join(proj, "sess-a.jsonl"))
+	if st, _ := ix.Update(logDir); st.Removed != 1 || ix.Len() != 1 {
+		t.Errorf("after removal: Update = %+v, Len = %d", st, ix.Len())
+	}
+	if v := ix.Version(); v != 2 {
+		t.Errorf("Version() = %d after removal, want 2", v)
+	}
+
+	projects, err := ix.QueryProjects(context.Background(), logDir, ProjectQuery{WithStats: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || !projects[0].HasStats || projects[0].MessageCount != 2 {
+		t.Errorf("projects = %+v", projects)
+	}
+
+	// A nil index parses directly.
+	var none *SessionIndex
+	if sessions, _ := none.ListSessions(logDir, "-work-app"); len(sessions) != 1 {
+		t.Errorf("nil index sessions = %d, want 1", len(sessions))
+	}
+	if err := none.Save(); err != nil {
+		t.Errorf("nil Save: %v", err)
+	}
+}
+
+func TestSessionIndexLoadSessionSummary(t *testing.T) {
+	logDir := t.TempDir()
+	proj := filepath.Join(logDir, "-work-app")
+	os.MkdirAll(proj, 0755)
+	writeTestSession(t, proj, "sess-a.jsonl", "2026-02-24T10:00:00.000Z", "First")
+
+	ix, err := OpenIndex(filepath.Join(t.TempDir(), "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		sess, err := ix.LoadSessionSummary(logDir, "-work-app", "sess-a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sess.FirstMessage != "First" {
+			t.Errorf("FirstMessage = %q, want %q", sess.FirstMessage, "First")
+		}
+	}
+	if cs := ix.CacheStats(); cs != (CacheStats{Hits: 1, Misses: 1}) {
+		t.Errorf("CacheStats() = %+v, want one miss then one hit", cs)
+	}
+	// A listing reuses the summary.
+	if _, err := ix.ListSessions(logDir, "-work-app"); err != nil {
+		t.Fatal(err)
+	}
+	if cs := ix.CacheStats(); cs.Misses != 1 {
+		t.Errorf("ListSessions reparsed: CacheStats() = %+v", cs)
+	}
+
+	if _, err := ix.LoadSessionSummary(logDir, "-work-app", "missing"); err == nil {
+		t.Error("LoadSessionSummary of a missing session should fail")
+	}
+}
@@ -1,79 +1,186 @@
 package logparser
 
-import "time"
-
-// LogEntry represents a single line from a JSONL log file.
-type LogEntry struct {
-	Type       string    `json:"type"`
-	UUID       string    `json:"uuid"`
-	ParentUUID *string   `json:"parentUuid"`
-	Timestamp  time.Time `json:"timestamp"`
-	SessionID  string    `json:"sessionId"`
-	Version    string    `json:"version,omitempty"`
-	CWD        string    `json:"cwd,omitempty"`
-	Message    Message   `json:"message"`
+import (
+	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// The entries of a session log, as decoded by pkg/claudelog. Entries of a
+// parsed Conversation always have a Message, which is empty for entry types
+// that carry none.
+type (
+	LogEntry        = claudelog.Entry
+	CompactMetadata = claudelog.CompactMetadata
+	FileSnapshot    = claudelog.FileSnapshot
+	FileBackup      = claudelog.FileBackup
+	Message         = claudelog.Message
+	Usage           = claudelog.Usage
+)
+
+// Project represents a project directory containing sessions.
+type Project struct {
+	Slug          string    `json:"slug"`
+	Path          string    `json:"path"`          // Decoded workspace path
+	PathAmbiguous bool      `json:"pathAmbiguous"` // Path could not be decoded with certainty
+	SessionCount  int       `json:"sessionCount"`
+	LastActivity  time.Time `json:"lastActivity"`
+
+	// Git repository the path belongs to. RepoRoot is the main worktree
+	// root, or Path itself when the project is not inside a repository.
+	RepoRoot string `json:"repoRoot"`
+	InGit    bool   `json:"inGit"`
+	Worktree bool   `json:"worktree,omitempty"` // Path is in a linked worktree
+
+	// Aggregates over all sessions. Only populated when HasStats is true,
+	// since computing them requires parsing every session file.
+	HasStats     bool     `json:"hasStats"`
+	MessageCount int      `json:"messageCount,omitempty"`
+	Tokens       int      `json:"tokens,omitempty"`
+	Cost         float64  `json:"cost,omitempty"`
+	Models       []string `json:"models,omitempty"`
 }
 
-// Message represents the message field in a log entry.
-type Message struct {
-	Role    string         `json:"role"`
-	Model   string         `json:"model,omitempty"`
-	Content MessageContent `json:"-"`
-	Usage   *Usage         `json:"usage,omitempty"`
+// Session represents a single conversation session.
+type Session struct {
+	ID           string    `json:"id"`
+	Slug         string    `json:"slug"`
+	FirstMessage string    `json:"firstMessage"`
+	Timestamp    time.Time `json:"timestamp"`
+	MessageCount int       `json:"messageCount"`
+	Model        string    `json:"model,omitempty"`  // First model used
+	Models       []string  `json:"models,omitempty"` // All models, in order of first use
+	InputTokens  int       `json:"inputTokens"`
+	OutputTokens int       `json:"outputTokens"`
+	Cost         float64   `json:"cost"` // Estimated USD
+	Branches     []string  `json:"branches,omitempty"`
+	Versions     []string  `json:"versions,omitempty"`
+	CWDChanged   bool      `json:"cwdChanged,omitempty"`
 
-	// RawContent holds the raw JSON for deferred parsing of content.
-	RawContent interface{} `json:"content"`
+	Failures   FailureCounts `json:"failures"`             // Failed tool calls
+	Interrupts int           `json:"interrupts,omitempty"` // Responses interrupted by the user
 }
 
-// MessageContent can be either a string or an array of content blocks.
-type MessageContent struct {
-	Text   string         // When content is a plain string
-	Blocks []ContentBlock // When content is an array
+// Tokens returns the total input and output tokens of the session.
+func (s Session) Tokens() int {
+	return s.InputTokens + s.OutputTokens
 }
 
-// ContentBlock represents a single element in an assistant's content array.
-type ContentBlock struct {
-	Type  string                 `json:"type"`
-	Text  string                 `json:"text,omitempty"`
-	ID    string                 `json:"id,omitempty"`
-	Name  string                 `json:"name,omitempty"`
-	Input map[string]interface{} `json:"input,omitempty"`
-
-	// For tool_result blocks in user messages
-	ToolUseID string      `json:"tool_use_id,omitempty"`
-	Content   interface{} `json:"content,omitempty"`
+// allModels returns Models, falling back to Model for summaries that only
+// record the first one.
+func (s Session) allModels() []string {
+	if len(s.Models) == 0 && s.Model != "" {
+		return []string{s.Model}
+	}
+	return s.Models
 }
 
-// Usage tracks token consumption.
-type Usage struct {
-	InputTokens              int `json:"input_tokens"`
-	OutputTokens             int `json:"output_tokens"`
-	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
-	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+// Conversation holds all entries for a single session view.
+type Conversation struct {
+	SessionID          string       `json:"sessionId"`
+	Entries            []LogEntry   `json:"entries"`
+	TotalInput         int          `json:"inputTokens"`
+	TotalOutput        int          `json:"outputTokens"`
+	TotalCacheRead     int          `json:"cacheReadTokens"`
+	TotalCacheCreation int          `json:"cacheCreationTokens"`
+	Cost               float64      `json:"cost"`            // Estimated USD
+	Model              string       `json:"model,omitempty"` // First model used
+	Models             []ModelUsage `json:"models,omitempty"`
+
+	// Environment seen during the session, in order of first appearance.
+	Branches []string `json:"branches,omitempty"` // Git branches
+	Versions []string `json:"versions,omitempty"` // Claude Code CLI versions
+	CWDs     []string `json:"cwds,omitempty"`     // Working directories
+
+	// File history checkpoints, in log order. Not included in Entries.
+	Snapshots []FileSnapshot `json:"snapshots,omitempty"`
+
+	// Tool call outcomes, keyed by tool_use ID.
+	ToolCalls  ToolCalls     `json:"toolCalls,omitempty"`
+	Failures   FailureCounts `json:"failures"`
+	Interrupts int           `json:"interrupts,omitempty"`
+
+	// Problems found while parsing the file.
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+
+	// modelChanges holds the assistant entries whose model differs from
+	// the previous assistant entry's.
+	modelChanges map[string]bool
+
+	// merged maps the UUIDs of streamed fragments to the entry they were
+	// merged into.
+	merged map[string]string
 }
 
-// Project represents a project directory containing sessions.
-type Project struct {
-	Slug         string
-	Path         string // Decoded workspace path
-	SessionCount int
-	LastActivity time.Time
+// EntryUUID returns the UUID of the entry that holds uuid: the entry
+// itself, or the first fragment of a streamed message it was merged into.
+func (c *Conversation) EntryUUID(uuid string) string {
+	if u, ok := c.merged[uuid]; ok {
+		return u
+	}
+	return uuid
 }
 
-// Session represents a single conversation session.
-type Session struct {
-	ID           string
-	FirstMessage string
-	Timestamp    time.Time
-	MessageCount int
-	Model        string
+// HasEntry reports whether uuid is an entry of the conversation or a
+// streamed fragment merged into one.
+func (c *Conversation) HasEntry(uuid string) bool {
+	if _, ok := c.merged[uuid]; ok {
+		return true
+	}
+	for i := range c.Entries {
+		if c.Entries[i].UUID == uuid {
+			return true
+		}
+	}
+	return false
 }
 
-// Conversation holds all entries for a single session view.
-type Conversation struct {
-	SessionID   string
-	Entries     []LogEntry
-	TotalInput  int
-	TotalOutput int
-	Model       string
+// ModelUsage is the part of a session handled by a single model.
+type ModelUsage struct {
+	Model               string  `json:"model"`
+	Messages            int     `json:"messages"` // Assistant messages
+	InputTokens         int     `json:"inputTokens"`
+	OutputTokens        int     `json:"outputTokens"`
+	CacheReadTokens     int     `json:"cacheReadTokens"`
+	CacheCreationTokens int     `json:"cacheCreationTokens"`
+	Cost                float64 `json:"cost"` // Estimated USD
+}
+
+// Tokens returns the input and output tokens of the model.
+func (m ModelUsage) Tokens() int {
+	return m.InputTokens + m.OutputTokens
+}
+
+// ModelChanged reports whether the assistant entry with the given UUID
+// was answered by a different model than the assistant entry before it.
+func (c *Conversation) ModelChanged(uuid string) bool {
+	return c.modelChanges[uuid]
+}
+
+// CWDChanged reports whether the working directory changed mid-session.
+func (c *Conversation) CWDChanged() bool {
+	return len(c.CWDs) > 1
+}
+
+// TimeRange returns the first and last entry timestamps, skipping entries
+// such as summaries that have none. Both are zero if no entry has one.
+func (c *Conversation) TimeRange() (start, end time.Time) {
+	for _, e := range c.Entries {
+		if !e.Timestamp.IsZero() {
+			if start.IsZero() {
+				start = e.Timestamp
+			}
+			end = e.Timestamp
+		}
+	}
+	return start, end
+}
+
+// baseCWD returns the working directory the session started in, against
+// which file paths are made relative.
+func (c *Conversation) baseCWD() string {
+	if len(c.CWDs) == 0 {
+		return ""
+	}
+	return c.CWDs[0]
 }
@@ -0,0 +1,153 @@
+package logparser
+
+import (
+	"strings"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// Outcomes of a tool call, as classified from its tool result.
+const (
+	OutcomeSuccess     = "success"
+	OutcomeError       = "error"       // Result flagged is_error
+	OutcomeExitCode    = "exit_code"   // Bash command exited non-zero
+	OutcomeRejected    = "rejected"    // User rejected the permission prompt
+	OutcomeInterrupted = "interrupted" // User interrupted the call
+	OutcomePending     = "pending"     // No result recorded
+)
+
+// Markers Claude Code writes into tool results and user messages.
+const (
+	interruptMarker = "[Request interrupted by user"
+	rejectMarker    = "The user doesn't want to proceed with this tool use"
+	exitCodePrefix  = "Exit code "
+)
+
+// ToolCall is a tool invocation and the classified outcome of its result.
+type ToolCall struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Outcome string `json:"outcome"`
+	// Detail is the first line of the result of a failed call.
+	Detail string `json:"detail,omitempty"`
+}
+
+// Failed reports whether the call did not complete successfully.
+func (t ToolCall) Failed() bool {
+	return t.Outcome != OutcomeSuccess && t.Outcome != OutcomePending && t.Outcome != ""
+}
+
+// ToolCalls indexes a conversation's tool calls by tool_use ID.
+type ToolCalls map[string]ToolCall
+
+// AnyFailed reports whether any tool_use or tool_result block refers to a
+// failed call.
+func (tc ToolCalls) AnyFailed(blocks []claudelog.Block) bool {
+	for _, b := range blocks {
+		var id string
+		switch b := b.(type) {
+		case *claudelog.ToolUseBlock:
+			id = b.ID
+		case *claudelog.ToolResultBlock:
+			id = b.ToolUseID
+		}
+		if id != "" && tc[id].Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// FailureCounts tallies the failed tool calls of a session by outcome.
+type FailureCounts struct {
+	Error       int `json:"error,omitempty"`
+	ExitCode    int `json:"exitCode,omitempty"`
+	Rejected    int `json:"rejected,omitempty"`
+	Interrupted int `json:"interrupted,omitempty"`
+}
+
+// Total returns the number of failed tool calls.
+func (f FailureCounts) Total() int {
+	return f.Error + f.ExitCode + f.Rejected + f.Interrupted
+}
+
+func (f *FailureCounts) add(outcome string) {
+	switch outcome {
+	case OutcomeError:
+		f.Error++
+	case OutcomeExitCode:
+		f.ExitCode++
+	case OutcomeRejected:
+		f.Rejected++
+	case OutcomeInterrupted:
+		f.Interrupted++
+	}
+}
+
+// IsInterrupt reports whether the entry is the marker Claude Code records
+// when the user interrupts a response.
+func IsInterrupt(e LogEntry) bool {
+	if e.Type != "user" {
+		return false
+	}
+	if strings.HasPrefix(e.Message.Content.Text, interruptMarker) {
+		return true
+	}
+	for _, b := range e.Message.Content.Blocks {
+		if t, ok := b.(*claudelog.TextBlock); ok && strings.HasPrefix(t.Text, interruptMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyToolCalls pairs every tool_use with its tool_result and fills the
+// conversation's ToolCalls, Failures and Interrupts.
+func (c *Conversation) classifyToolCalls() {
+	c.ToolCalls = make(ToolCalls)
+	c.Failures, c.Interrupts = FailureCounts{}, 0
+	var order []string
+	for _, e := range c.Entries {
+		if IsInterrupt(e) {
+			c.Interrupts++
+		}
+		for _, b := range e.Message.Content.Blocks {
+			switch b := b.(type) {
+			case *claudelog.ToolUseBlock:
+				if _, ok := c.ToolCalls[b.ID]; !ok {
+					order = append(order, b.ID)
+				}
+				c.ToolCalls[b.ID] = ToolCall{ID: b.ID, Name: b.Name, Outcome: OutcomePending}
+			case *claudelog.ToolResultBlock:
+				call := c.ToolCalls[b.ToolUseID]
+				call.ID = b.ToolUseID
+				call.Outcome, call.Detail = classifyResult(b)
+				c.ToolCalls[b.ToolUseID] = call
+			}
+		}
+	}
+	for _, id := range order {
+		c.Failures.add(c.ToolCalls[id].Outcome)
+	}
+}
+
+// classifyResult determines the outcome of a tool_result block.
+func classifyResult(b *claudelog.ToolResultBlock) (outcome, detail string) {
+	text := strings.TrimSpace(ResultText(b.Content))
+	first, _, _ := strings.Cut(text, "\n")
+	first = strings.TrimPrefix(first, "<tool_use_error>")
+	first = strings.TrimSuffix(first, "</tool_use_error>")
+
+	switch {
+	case strings.HasPrefix(text, interruptMarker):
+		return OutcomeInterrupted, first
+	case strings.HasPrefix(text, rejectMarker):
+		return OutcomeRejected, first
+	case !b.IsError && !strings.HasPrefix(text, "<tool_use_error>"):
+		return OutcomeSuccess, ""
+	case strings.HasPrefix(text, exitCodePrefix):
+		return OutcomeExitCode, first
+	default:
+		return OutcomeError, first
+	}
+}
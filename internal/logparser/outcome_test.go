@@ -0,0 +1,64 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+func TestClassifyToolCalls(t *testing.T) {
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T10:00:00Z","sessionId":"sess-1","message":{"role":"user","content":"Go"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T10:00:01Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"tool_use","id":"ok","name":"Read","input":{}},{"type":"tool_use","id":"err","name":"Edit","input":{}},{"type":"tool_use","id":"exit","name":"Bash","input":{}},{"type":"tool_use","id":"rej","name":"Write","input":{}},{"type":"tool_use","id":"int","name":"Bash","input":{}},{"type":"tool_use","id":"pending","name":"Bash","input":{}}]}}
+{"type":"user","uuid":"r1","timestamp":"2026-02-25T10:00:02Z","sessionId":"sess-1","message":{"role":"user","content":[` +
+		`{"type":"tool_result","tool_use_id":"ok","content":[{"type":"text","text":"file contents"}]},` +
+		`{"type":"tool_result","tool_use_id":"err","content":"<tool_use_error>String to replace not found in file.</tool_use_error>","is_error":true},` +
+		`{"type":"tool_result","tool_use_id":"exit","content":"Exit code 2\nmake: *** No rule","is_error":true},` +
+		`{"type":"tool_result","tool_use_id":"rej","content":"The user doesn't want to proceed with this tool use. The tool use was rejected.","is_error":true},` +
+		`{"type":"tool_result","tool_use_id":"int","content":"[Request interrupted by user for tool use]","is_error":true}]}}
+{"type":"user","uuid":"u2","timestamp":"2026-02-25T10:00:03Z","sessionId":"sess-1","message":{"role":"user","content":[{"type":"text","text":"[Request interrupted by user for tool use]"}]}}
+`
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wants := map[string]string{
+		"ok":      OutcomeSuccess,
+		"err":     OutcomeError,
+		"exit":    OutcomeExitCode,
+		"rej":     OutcomeRejected,
+		"int":     OutcomeInterrupted,
+		"pending": OutcomePending,
+	}
+	for id, want := range wants {
+		if got := conv.ToolCalls[id].Outcome; got != want {
+			t.Errorf("outcome of %s = %q, want %q", id, got, want)
+		}
+	}
+	if got := conv.ToolCalls["err"].Detail; got != "String to replace not found in file." {
+		t.Errorf("Detail = %q, want the unwrapped error", got)
+	}
+	if got := conv.ToolCalls["exit"].Detail; got != "Exit code 2" {
+		t.Errorf("Detail = %q, want %q", got, "Exit code 2")
+	}
+
+	want := FailureCounts{Error: 1, ExitCode: 1, Rejected: 1, Interrupted: 1}
+	if conv.Failures != want {
+		t.Errorf("Failures = %+v, want %+v", conv.Failures, want)
+	}
+	if conv.Interrupts != 1 {
+		t.Errorf("Interrupts = %d, want 1", conv.Interrupts)
+	}
+	if r, ok := conv.Entries[2].Message.Content.Blocks[1].(*claudelog.ToolResultBlock); !ok || !r.IsError || !conv.ToolCalls.AnyFailed(conv.Entries[1].Message.Content.Blocks) {
+		t.Error("is_error should be parsed and AnyFailed should flag the assistant entry")
+	}
+	if s := summarize("proj", conv); s.Failures.Total() != 4 || s.Interrupts != 1 {
+		t.Errorf("summary failures = %+v, interrupts = %d", s.Failures, s.Interrupts)
+	}
+}
@@ -0,0 +1,60 @@
+package logparser
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Workers bounds how many session files are scanned or parsed at once when
+// listing projects and sessions, across all concurrent listings. It
+// defaults to GOMAXPROCS; set it before listing, e.g. to 1 to parse
+// serially.
+var Workers = runtime.GOMAXPROCS(0)
+
+var (
+	slotsMu sync.Mutex
+	slots   chan struct{} // Semaphore of Workers slots shared by forEach calls
+)
+
+// workerSlots returns the semaphore shared by forEach calls, replacing it
+// if Workers has changed.
+func workerSlots() chan struct{} {
+	slotsMu.Lock()
+	defer slotsMu.Unlock()
+	if n := max(Workers, 1); cap(slots) != n {
+		slots = make(chan struct{}, n)
+	}
+	return slots
+}
+
+// forEach calls fn for every index in [0, n) and waits for the calls to
+// finish. Each call holds one of Workers slots shared with every other
+// forEach, so concurrent listings together use at most Workers goroutines
+// for the work; fn must not call forEach itself. Once ctx is done no
+// further calls are started, and forEach returns ctx's error.
+func forEach(ctx context.Context, n int, fn func(i int)) error {
+	sem := workerSlots()
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(cap(sem), n) {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				i := int(next.Add(1)) - 1
+				if i >= n {
+					return
+				}
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				fn(i)
+				<-sem
+			}
+		})
+	}
+	wg.Wait()
+	return ctx.Err()
+}
@@ -0,0 +1,105 @@
+package logparser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	defer func(n int) { Workers = n }(Workers)
+	Workers = 3
+
+	var running, peak atomic.Int64
+	calls := make([]int, 50)
+	err := forEach(context.Background(), len(calls), func(i int) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		calls[i]++
+		running.Add(-1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range calls {
+		if n != 1 {
+			t.Errorf("fn(%d) called %d times, want 1", i, n)
+		}
+	}
+	if p := peak.Load(); p > 3 {
+		t.Errorf("%d calls ran at once, want at most 3", p)
+	}
+}
+
+func TestForEachShared(t *testing.T) {
+	defer func(n int) { Workers = n }(Workers)
+	Workers = 2
+
+	// Concurrent calls share the workers rather than having their own.
+	var running, peak atomic.Int64
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			forEach(context.Background(), 20, func(i int) {
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+			})
+		})
+	}
+	wg.Wait()
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d calls ran at once across listings, want at most 2", p)
+	}
+}
+
+func TestForEachCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int64
+	err := forEach(ctx, 1000, func(i int) {
+		if calls.Add(1) == 5 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if n := calls.Load(); n >= 1000 {
+		t.Errorf("%d calls made after cancel, want the rest skipped", n)
+	}
+}
+
+func TestListContextCanceled(t *testing.T) {
+	dir := t.TempDir()
+	proj := filepath.Join(dir, "-work-app")
+	os.MkdirAll(proj, 0755)
+	writeTestSession(t, proj, "s1.jsonl", "2026-02-24T10:00:00.000Z", "Hello")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ListProjectsContext(ctx, dir); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListProjectsContext err = %v, want context.Canceled", err)
+	}
+	if _, err := ListSessionsContext(ctx, dir, "-work-app"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListSessionsContext err = %v, want context.Canceled", err)
+	}
+	if _, err := QueryProjects(ctx, dir, ProjectQuery{WithStats: true}); !errors.Is(err, context.Canceled) {
+		t.Errorf("QueryProjects err = %v, want context.Canceled", err)
+	}
+}
@@ -1,15 +1,21 @@
 package logparser
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log/slog"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
 )
 
 // DecodeSlug converts a project directory slug back to the original workspace path.
@@ -17,7 +23,9 @@ import (
 // but `.` in the original path was kept as-is.
 //
 // The slug is formed by replacing `/` with `-` in the absolute path,
-// which means the leading `/` becomes a leading `-`.
+// which means the leading `/` becomes a leading `-`. Hyphens in the original
+// path are indistinguishable from separators, so this is a best-effort guess;
+// use ResolveProjectPath to recover the true path.
 func DecodeSlug(slug string) string {
 	if len(slug) == 0 {
 		return ""
@@ -28,121 +36,392 @@ func DecodeSlug(slug string) string {
 	return strings.ReplaceAll(slug, "-", "/")
 }
 
-// ParseEntry parses a single JSONL line into a LogEntry.
+// Counts of the parse errors in each session file, as of its latest parse,
+// so that parsing a file again does not count its errors twice.
+var (
+	parseErrorsMu sync.Mutex
+	parseErrors   = make(map[string]ParseErrorCounts)
+)
+
+// ParseErrorCounts reports the parse errors skipped over in session files.
+type ParseErrorCounts struct {
+	MalformedLines int64 // Lines that are not valid JSON entries
+	ContentErrors  int64 // Entries whose content blocks could not be decoded
+}
+
+// ParseErrors returns the parse errors in the session files parsed since
+// the process started, counting each file as of its latest parse.
+func ParseErrors() ParseErrorCounts {
+	parseErrorsMu.Lock()
+	defer parseErrorsMu.Unlock()
+	var total ParseErrorCounts
+	for _, c := range parseErrors {
+		total.MalformedLines += c.MalformedLines
+		total.ContentErrors += c.ContentErrors
+	}
+	return total
+}
+
+// recordParseErrors replaces the parse error counts of file with those of
+// its diagnostics.
+func recordParseErrors(file string, diags []Diagnostic) {
+	var c ParseErrorCounts
+	for _, d := range diags {
+		switch d.Kind {
+		case DiagMalformedLine:
+			c.MalformedLines++
+		case DiagBadContent:
+			c.ContentErrors++
+		}
+	}
+	parseErrorsMu.Lock()
+	defer parseErrorsMu.Unlock()
+	if c == (ParseErrorCounts{}) {
+		delete(parseErrors, file)
+	} else {
+		parseErrors[file] = c
+	}
+}
+
+// ParseEntry parses a single JSONL line into a LogEntry. Content blocks
+// that fail to decode do not fail the entry; check entry.ContentErr.
+// ParseSession reports them as DiagBadContent diagnostics.
 func ParseEntry(line []byte) (LogEntry, error) {
 	var entry LogEntry
 	if err := json.Unmarshal(line, &entry); err != nil {
 		return LogEntry{}, fmt.Errorf("parse entry: %w", err)
 	}
+	if entry.Message == nil {
+		entry.Message = new(Message)
+	}
+	return entry, nil
+}
 
-	// Parse the content field which can be string or array
-	switch raw := entry.Message.RawContent.(type) {
-	case string:
-		entry.Message.Content = MessageContent{Text: raw}
-	case []interface{}:
-		data, _ := json.Marshal(raw)
-		var blocks []ContentBlock
-		if err := json.Unmarshal(data, &blocks); err != nil {
-			slog.Warn("failed to parse content blocks", "error", err)
-		}
-		entry.Message.Content = MessageContent{Blocks: blocks}
+// contentErr returns the error decoding the entry's content blocks, which
+// leaves the entry usable without them.
+func contentErr(e *LogEntry) error {
+	err := e.ContentErr()
+	if err == nil {
+		return nil
 	}
+	return fmt.Errorf("parse content blocks: %w", err)
+}
 
-	return entry, nil
+// ParseOptions controls ParseSession.
+type ParseOptions struct {
+	// UnknownFields reports top-level entry fields that pkg/claudelog does
+	// not model.
+	UnknownFields bool
 }
 
 // ParseSessionFile reads a JSONL file and returns a Conversation.
-// Skips malformed lines and progress/file-history-snapshot entries.
+// Skips malformed lines and progress entries; file-history-snapshot entries
+// are collected into Snapshots. Claude Code logs each content block of a
+// streamed response as its own entry; these are merged back into one.
+// Lines that could not be parsed are recorded in Diagnostics.
 func ParseSessionFile(path string) (*Conversation, error) {
+	return ParseSession(path, ParseOptions{})
+}
+
+// ParseSession is ParseSessionFile with options.
+func ParseSession(path string, opts ParseOptions) (*Conversation, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("open session file: %w", err)
 	}
 	defer f.Close()
 
-	conv := &Conversation{
-		SessionID: strings.TrimSuffix(filepath.Base(path), ".jsonl"),
+	p := newSessionParser(path, opts)
+	if err := p.parse(f); err != nil {
+		return nil, err
 	}
+	return p.finish(), nil
+}
 
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 0, 1024*1024), 10*1024*1024) // 10MB max line size
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
+// sessionParser builds a Conversation from the lines of a session log. It
+// can be resumed to parse lines appended to the log later.
+type sessionParser struct {
+	conv      *Conversation
+	byMessage map[string]int // API message key -> index in conv.Entries
+	diag      *diagnoser
+	lines     int   // Lines read
+	offset    int64 // Bytes read
+
+	// partial is set when the last line read did not end in a newline, as
+	// when it was still being written. Such a parser cannot be resumed.
+	partial bool
+}
+
+func newSessionParser(path string, opts ParseOptions) *sessionParser {
+	return &sessionParser{
+		conv:      &Conversation{SessionID: strings.TrimSuffix(filepath.Base(path), ".jsonl")},
+		byMessage: make(map[string]int),
+		diag:      newDiagnoser(path, opts.UnknownFields),
+	}
+}
+
+// parse reads entries from r, which continues the log at p.offset.
+func (p *sessionParser) parse(r io.Reader) error {
+	conv, diag := p.conv, p.diag
+	cr := &countingReader{r: r}
+	lr := claudelog.NewReader(cr)
+	start, lines := p.offset, p.lines
+	for entry, err := range lr.All() {
+		line, offset := lr.Pos()
+		pos := diag.pos(lines+line, start+offset)
+		var syntax *claudelog.SyntaxError
+		if errors.As(err, &syntax) {
+			diag.add(pos, DiagMalformedLine, fmt.Errorf("parse entry: %w", syntax.Err))
 			continue
 		}
-
-		entry, err := ParseEntry(line)
 		if err != nil {
-			slog.Warn("skipping malformed JSONL line", "error", err, "file", path)
-			continue
+			return fmt.Errorf("scan session file: %w", err)
+		}
+		if err := contentErr(entry); err != nil {
+			diag.add(pos, DiagBadContent, err)
 		}
+		diag.entry(pos, entry)
 
+		if entry.Type == "file-history-snapshot" {
+			if entry.Snapshot != nil {
+				conv.Snapshots = append(conv.Snapshots, *entry.Snapshot)
+			}
+			continue
+		}
 		// Skip noise entries
-		if entry.Type == "progress" || entry.Type == "file-history-snapshot" {
+		if entry.Type == "progress" {
 			continue
 		}
-
-		// Accumulate token usage
-		if entry.Message.Usage != nil {
-			conv.TotalInput += entry.Message.Usage.InputTokens
-			conv.TotalOutput += entry.Message.Usage.OutputTokens
+		if entry.Message == nil {
+			entry.Message = new(Message)
 		}
 
 		// Capture model name from first assistant message
-		if conv.Model == "" && entry.Message.Model != "" {
+		if conv.Model == "" && entry.Message.Model != "" && entry.Message.Model != syntheticModel {
 			conv.Model = entry.Message.Model
 		}
 
-		conv.Entries = append(conv.Entries, entry)
+		conv.Branches = appendUnique(conv.Branches, entry.GitBranch)
+		conv.Versions = appendUnique(conv.Versions, entry.Version)
+		conv.CWDs = appendUnique(conv.CWDs, entry.CWD)
+
+		if key := messageKey(entry); key != "" {
+			if i, ok := p.byMessage[key]; ok {
+				mergeFragment(&conv.Entries[i], entry)
+				if conv.merged == nil {
+					conv.merged = make(map[string]string)
+				}
+				conv.merged[entry.UUID] = conv.Entries[i].UUID
+				continue
+			}
+			p.byMessage[key] = len(conv.Entries)
+		}
+		conv.Entries = append(conv.Entries, *entry)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("scan session file: %w", err)
+	p.lines, _ = lr.Pos()
+	p.lines += lines
+	p.offset = start + lr.Offset()
+	if end := start + cr.n; p.offset != end {
+		p.partial = p.offset > end
+		p.offset = end
 	}
+	return nil
+}
 
-	return conv, nil
+// finish computes the conversation's totals over the entries parsed so far
+// and returns it.
+func (p *sessionParser) finish() *Conversation {
+	conv := p.conv
+	conv.Diagnostics = p.diag.diags
+	recordParseErrors(p.diag.file, conv.Diagnostics)
+
+	// Accumulate token usage once fragments are merged, so each API call
+	// is counted once.
+	conv.TotalInput, conv.TotalOutput, conv.TotalCacheRead, conv.TotalCacheCreation = 0, 0, 0, 0
+	conv.Cost = 0
+	for _, e := range conv.Entries {
+		if u := e.Message.Usage; u != nil {
+			conv.TotalInput += u.InputTokens
+			conv.TotalOutput += u.OutputTokens
+			conv.TotalCacheRead += u.CacheReadInputTokens
+			conv.TotalCacheCreation += u.CacheCreationInputTokens
+			conv.Cost += EstimateCost(e.Message.Model, u)
+		}
+	}
+	conv.countModels()
+
+	conv.classifyToolCalls()
+	return conv
 }
 
-// ListProjects scans the log directory for project subdirectories.
-func ListProjects(logDir string) ([]Project, error) {
-	entries, err := os.ReadDir(logDir)
-	if err != nil {
-		return nil, fmt.Errorf("read log dir: %w", err)
+// resume returns a parser that continues where p stopped. It builds a new
+// Conversation, leaving the one p built unchanged for those still using it.
+func (p *sessionParser) resume() *sessionParser {
+	conv := *p.conv
+	conv.Entries = slices.Clone(conv.Entries) // Fragments are merged into earlier entries
+	conv.Snapshots = slices.Clip(conv.Snapshots)
+	conv.Branches = slices.Clip(conv.Branches)
+	conv.Versions = slices.Clip(conv.Versions)
+	conv.CWDs = slices.Clip(conv.CWDs)
+	conv.merged = maps.Clone(conv.merged)
+
+	diag := *p.diag
+	diag.diags = slices.Clone(diag.diags) // Counts are updated in place
+	diag.seen = maps.Clone(diag.seen)
+
+	return &sessionParser{
+		conv:      &conv,
+		byMessage: maps.Clone(p.byMessage),
+		diag:      &diag,
+		lines:     p.lines,
+		offset:    p.offset,
 	}
+}
 
-	var projects []Project
-	for _, e := range entries {
-		if !e.IsDir() {
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(b []byte) (int, error) {
+	n, err := cr.r.Read(b)
+	cr.n += int64(n)
+	return n, err
+}
+
+// syntheticModel is the model Claude Code records on messages it generates
+// itself, such as API error notices.
+const syntheticModel = "<synthetic>"
+
+// countModels tallies usage per model and marks the entries where the model
+// changes.
+func (c *Conversation) countModels() {
+	c.Models = nil
+	c.modelChanges = make(map[string]bool)
+	byModel := make(map[string]int)
+	prev := ""
+	for _, e := range c.Entries {
+		model := e.Message.Model
+		if e.Type != "assistant" || model == "" || model == syntheticModel {
 			continue
 		}
-		slug := e.Name()
-		projDir := filepath.Join(logDir, slug)
+		if prev != "" && model != prev {
+			c.modelChanges[e.UUID] = true
+		}
+		prev = model
 
-		sessionFiles, _ := filepath.Glob(filepath.Join(projDir, "*.jsonl"))
-		if len(sessionFiles) == 0 {
-			continue
+		i, ok := byModel[model]
+		if !ok {
+			i = len(c.Models)
+			byModel[model] = i
+			c.Models = append(c.Models, ModelUsage{Model: model})
+		}
+		m := &c.Models[i]
+		m.Messages++
+		if u := e.Message.Usage; u != nil {
+			m.InputTokens += u.InputTokens
+			m.OutputTokens += u.OutputTokens
+			m.CacheReadTokens += u.CacheReadInputTokens
+			m.CacheCreationTokens += u.CacheCreationInputTokens
+			m.Cost += EstimateCost(model, u)
+		}
+	}
+}
+
+// messageKey identifies the API response an assistant entry belongs to.
+func messageKey(e *LogEntry) string {
+	if e.Type != "assistant" {
+		return ""
+	}
+	if e.Message.ID != "" {
+		return e.Message.ID
+	}
+	return e.RequestID
+}
+
+// mergeFragment appends the content of a later fragment of the same API
+// response to dst. Every fragment repeats the response's usage, with output
+// tokens counted up to that point, so the largest values are kept. The
+// message, blocks and usage of dst are replaced rather than modified, as a
+// resumed parser's entries share them with the conversation it was resumed
+// from.
+func mergeFragment(dst, src *LogEntry) {
+	m := *dst.Message
+	dst.Message = &m
+	if t := m.Content.Text; t != "" {
+		m.Content = MessageContent{Blocks: []claudelog.Block{&claudelog.TextBlock{Text: t}}, IsBlocks: true}
+	}
+	blocks := src.Message.Content.Blocks
+	if t := src.Message.Content.Text; t != "" {
+		blocks = []claudelog.Block{&claudelog.TextBlock{Text: t}}
+	}
+	m.Content.Blocks = slices.Concat(m.Content.Blocks, blocks)
+
+	if u := src.Message.Usage; u != nil {
+		d := new(Usage)
+		if dst.Message.Usage != nil {
+			*d = *dst.Message.Usage
+		}
+		dst.Message.Usage = d
+		d.InputTokens = max(d.InputTokens, u.InputTokens)
+		d.OutputTokens = max(d.OutputTokens, u.OutputTokens)
+		d.CacheCreationInputTokens = max(d.CacheCreationInputTokens, u.CacheCreationInputTokens)
+		d.CacheReadInputTokens = max(d.CacheReadInputTokens, u.CacheReadInputTokens)
+	}
+}
+
+// ListProjects scans the log directory for project subdirectories.
+func ListProjects(logDir string) ([]Project, error) {
+	return ListProjectsContext(context.Background(), logDir)
+}
+
+// ListProjectsContext is ListProjects, scanning projects concurrently and
+// giving up once ctx is done.
+func ListProjectsContext(ctx context.Context, logDir string) ([]Project, error) {
+	w := claudelog.Walker{Root: logDir}
+	slugs, err := w.Projects()
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]*Project, len(slugs))
+	err = forEach(ctx, len(slugs), func(i int) {
+		slug := slugs[i]
+		sessionFiles, err := w.Sessions(slug)
+		if err != nil || len(sessionFiles) == 0 {
+			return
 		}
 
 		var lastActivity time.Time
 		for _, sf := range sessionFiles {
-			info, err := os.Stat(sf)
-			if err != nil {
-				continue
-			}
-			if info.ModTime().After(lastActivity) {
-				lastActivity = info.ModTime()
+			if sf.ModTime.After(lastActivity) {
+				lastActivity = sf.ModTime
 			}
 		}
 
-		projects = append(projects, Project{
-			Slug:         slug,
-			Path:         DecodeSlug(slug),
-			SessionCount: len(sessionFiles),
-			LastActivity: lastActivity,
-		})
+		pp := ResolveProjectPath(logDir, slug)
+		p := &Project{
+			Slug:          slug,
+			Path:          pp.Path,
+			PathAmbiguous: pp.Ambiguous,
+			SessionCount:  len(sessionFiles),
+			LastActivity:  lastActivity,
+		}
+		p.setRepo()
+		found[i] = p
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	var projects []Project
+	for _, p := range found {
+		if p != nil {
+			projects = append(projects, *p)
+		}
+	}
 	sort.Slice(projects, func(i, j int) bool {
 		return projects[i].LastActivity.After(projects[j].LastActivity)
 	})
@@ -152,57 +431,96 @@ func ListProjects(logDir string) ([]Project, error) {
 
 // ListSessions returns all sessions for a given project slug.
 func ListSessions(logDir, slug string) ([]Session, error) {
-	projDir := filepath.Join(logDir, slug)
-	sessionFiles, err := filepath.Glob(filepath.Join(projDir, "*.jsonl"))
+	return ListSessionsContext(context.Background(), logDir, slug)
+}
+
+// ListSessionsContext is ListSessions, parsing sessions concurrently and
+// giving up once ctx is done.
+func ListSessionsContext(ctx context.Context, logDir, slug string) ([]Session, error) {
+	var ix *SessionIndex
+	sessions, _, err := ix.listSessions(ctx, logDir, slug)
+	return sessions, err
+}
+
+// LoadSessionSummary returns the list-view summary of a single session.
+func LoadSessionSummary(logDir, slug, sessionID string) (Session, error) {
+	conv, err := LoadSession(logDir, slug, sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("glob sessions: %w", err)
+		return Session{}, err
 	}
+	return summarize(slug, conv), nil
+}
 
-	var sessions []Session
-	for _, sf := range sessionFiles {
-		conv, err := ParseSessionFile(sf)
-		if err != nil {
-			slog.Warn("skipping session file", "error", err, "file", sf)
-			continue
-		}
+// summarize builds the list-view summary of a parsed conversation.
+func summarize(slug string, conv *Conversation) Session {
+	sess := Session{
+		ID:           conv.SessionID,
+		Slug:         slug,
+		MessageCount: len(conv.Entries),
+		Model:        conv.Model,
+		InputTokens:  conv.TotalInput,
+		OutputTokens: conv.TotalOutput,
+		Cost:         conv.Cost,
+		Branches:     conv.Branches,
+		Versions:     conv.Versions,
+		CWDChanged:   conv.CWDChanged(),
+		Failures:     conv.Failures,
+		Interrupts:   conv.Interrupts,
+	}
+	for _, m := range conv.Models {
+		sess.Models = append(sess.Models, m.Model)
+	}
 
-		sess := Session{
-			ID:           conv.SessionID,
-			MessageCount: len(conv.Entries),
-			Model:        conv.Model,
+	// Find first user message and timestamp
+	for _, e := range conv.Entries {
+		if sess.Timestamp.IsZero() {
+			sess.Timestamp = e.Timestamp
 		}
-
-		// Find first user message and timestamp
-		for _, e := range conv.Entries {
-			if sess.Timestamp.IsZero() {
-				sess.Timestamp = e.Timestamp
-			}
-			if e.Type == "user" && sess.FirstMessage == "" {
-				text := e.Message.Content.Text
-				if text == "" && len(e.Message.Content.Blocks) > 0 {
-					text = "(tool results)"
-				}
-				sess.FirstMessage = truncate(text, 120)
-			}
-			if sess.FirstMessage != "" && !sess.Timestamp.IsZero() {
-				break
+		if e.Type == "user" && sess.FirstMessage == "" {
+			text := e.Message.Content.Text
+			if text == "" && len(e.Message.Content.Blocks) > 0 {
+				text = "(tool results)"
 			}
+			sess.FirstMessage = truncate(text, 120)
+		}
+		if sess.FirstMessage != "" && !sess.Timestamp.IsZero() {
+			break
 		}
-
-		sessions = append(sessions, sess)
 	}
-
-	sort.Slice(sessions, func(i, j int) bool {
-		return sessions[i].Timestamp.After(sessions[j].Timestamp)
-	})
-
-	return sessions, nil
+	return sess
 }
 
 // LoadSession loads a specific session file by project slug and session ID.
 func LoadSession(logDir, slug, sessionID string) (*Conversation, error) {
-	path := filepath.Join(logDir, slug, sessionID+".jsonl")
-	return ParseSessionFile(path)
+	sf, err := claudelog.Walker{Root: logDir}.Session(slug, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("open session file: %w", err)
+	}
+	return ParseSessionFile(sf.Path)
+}
+
+// FindSession returns the slug of the project containing the session with
+// the given ID.
+func FindSession(logDir, sessionID string) (string, error) {
+	w := claudelog.Walker{Root: logDir}
+	slugs, err := w.Projects()
+	if err != nil {
+		return "", fmt.Errorf("find session: %w", err)
+	}
+	for _, slug := range slugs {
+		if _, err := w.Session(slug, sessionID); err == nil {
+			return slug, nil
+		}
+	}
+	return "", fmt.Errorf("session %s not found", sessionID)
+}
+
+// appendUnique appends v to list unless it is empty or already present.
+func appendUnique(list []string, v string) []string {
+	if v == "" || slices.Contains(list, v) {
+		return list
+	}
+	return append(list, v)
 }
 
 func truncate(s string, max int) string {
@@ -3,8 +3,11 @@ package logparser
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
 )
 
 func TestDecodeSlug(t *testing.T) {
@@ -58,11 +61,11 @@ func TestParseEntry_AssistantWithToolUse(t *testing.T) {
 	if len(entry.Message.Content.Blocks) != 2 {
 		t.Fatalf("Content.Blocks length = %d, want 2", len(entry.Message.Content.Blocks))
 	}
-	if entry.Message.Content.Blocks[0].Type != "text" {
-		t.Errorf("Block[0].Type = %q, want %q", entry.Message.Content.Blocks[0].Type, "text")
+	if b := entry.Message.Content.Blocks[0]; b.BlockType() != "text" {
+		t.Errorf("Block[0] type = %q, want %q", b.BlockType(), "text")
 	}
-	if entry.Message.Content.Blocks[1].Name != "Bash" {
-		t.Errorf("Block[1].Name = %q, want %q", entry.Message.Content.Blocks[1].Name, "Bash")
+	if b, ok := entry.Message.Content.Blocks[1].(*claudelog.ToolUseBlock); !ok || b.Name != "Bash" {
+		t.Errorf("Block[1] = %#v, want a Bash tool use", entry.Message.Content.Blocks[1])
 	}
 	if entry.Message.Usage == nil {
 		t.Fatal("Usage should not be nil")
@@ -81,8 +84,8 @@ func TestParseEntry_UserWithToolResults(t *testing.T) {
 	if len(entry.Message.Content.Blocks) != 1 {
 		t.Fatalf("Content.Blocks length = %d, want 1", len(entry.Message.Content.Blocks))
 	}
-	if entry.Message.Content.Blocks[0].Type != "tool_result" {
-		t.Errorf("Block[0].Type = %q, want %q", entry.Message.Content.Blocks[0].Type, "tool_result")
+	if b := entry.Message.Content.Blocks[0]; b.BlockType() != "tool_result" {
+		t.Errorf("Block[0] type = %q, want %q", b.BlockType(), "tool_result")
 	}
 }
 
@@ -137,6 +140,136 @@ func TestParseSessionFile(t *testing.T) {
 	}
 }
 
+func TestParseSessionFile_Metadata(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"type":"user","uuid":"a","timestamp":"2026-02-25T06:41:55.945Z","sessionId":"sess-1","cwd":"/home/user/app","gitBranch":"main","version":"2.1.0","message":{"role":"user","content":"Hi"}}
+{"type":"assistant","uuid":"b","timestamp":"2026-02-25T06:42:02.218Z","sessionId":"sess-1","cwd":"/home/user/app","gitBranch":"main","version":"2.1.0","message":{"model":"claude-opus-4-6","role":"assistant","content":[{"type":"text","text":"Hello!"}]}}
+{"type":"user","uuid":"c","timestamp":"2026-02-25T06:43:00.000Z","sessionId":"sess-1","cwd":"/home/user/app/web","gitBranch":"feature/login","version":"2.1.1","message":{"role":"user","content":"Next"}}
+`
+	path := filepath.Join(dir, "sess-1.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(conv.Branches, ","); got != "main,feature/login" {
+		t.Errorf("Branches = %q, want %q", got, "main,feature/login")
+	}
+	if got := strings.Join(conv.Versions, ","); got != "2.1.0,2.1.1" {
+		t.Errorf("Versions = %q, want %q", got, "2.1.0,2.1.1")
+	}
+	if len(conv.CWDs) != 2 || !conv.CWDChanged() {
+		t.Errorf("CWDs = %v, want two entries and CWDChanged", conv.CWDs)
+	}
+
+	s := summarize("proj", conv)
+	if len(s.Branches) != 2 || len(s.Versions) != 2 || !s.CWDChanged {
+		t.Errorf("summary = %+v, want branches, versions and cwd change", s)
+	}
+}
+
+func TestParseSessionFile_StreamedFragments(t *testing.T) {
+	// One API response logged as four entries, one per content block, with
+	// a tool result written before the last fragment.
+	content := `{"parentUuid":null,"isSidechain":false,"userType":"external","cwd":"/home/user/app","sessionId":"sess-1","version":"2.1.0","gitBranch":"main","type":"user","message":{"role":"user","content":"Fix the build"},"uuid":"u1","timestamp":"2026-02-25T06:41:55.945Z"}
+{"parentUuid":"u1","isSidechain":false,"userType":"external","cwd":"/home/user/app","sessionId":"sess-1","version":"2.1.0","gitBranch":"main","message":{"id":"msg_01Hx","type":"message","role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"thinking","thinking":"Check the build first.","signature":"EqMB"}],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":4,"cache_creation_input_tokens":2048,"cache_read_input_tokens":12000,"cache_creation":{"ephemeral_5m_input_tokens":2048,"ephemeral_1h_input_tokens":0},"output_tokens":8,"service_tier":"standard"}},"requestId":"req_011C","type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:41:58.100Z"}
+{"parentUuid":"a1","isSidechain":false,"userType":"external","cwd":"/home/user/app","sessionId":"sess-1","version":"2.1.0","gitBranch":"main","message":{"id":"msg_01Hx","type":"message","role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"text","text":"Running the build."}],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":4,"cache_creation_input_tokens":2048,"cache_read_input_tokens":12000,"cache_creation":{"ephemeral_5m_input_tokens":2048,"ephemeral_1h_input_tokens":0},"output_tokens":8,"service_tier":"standard"}},"requestId":"req_011C","type":"assistant","uuid":"a2","timestamp":"2026-02-25T06:41:58.900Z"}
+{"parentUuid":"a2","isSidechain":false,"userType":"external","cwd":"/home/user/app","sessionId":"sess-1","version":"2.1.0","gitBranch":"main","message":{"id":"msg_01Hx","type":"message","role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"tool_use","id":"toolu_01","name":"Bash","input":{"command":"go build ./...","description":"Build"}}],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":4,"cache_creation_input_tokens":2048,"cache_read_input_tokens":12000,"cache_creation":{"ephemeral_5m_input_tokens":2048,"ephemeral_1h_input_tokens":0},"output_tokens":8,"service_tier":"standard"}},"requestId":"req_011C","type":"assistant","uuid":"a3","timestamp":"2026-02-25T06:41:59.200Z"}
+{"parentUuid":"a3","isSidechain":false,"userType":"external","cwd":"/home/user/app","sessionId":"sess-1","version":"2.1.0","gitBranch":"main","type":"user","message":{"role":"user","content":[{"tool_use_id":"toolu_01","type":"tool_result","content":"ok","is_error":false}]},"uuid":"r1","timestamp":"2026-02-25T06:42:01.000Z","toolUseResult":{"stdout":"ok","stderr":"","interrupted":false}}
+{"parentUuid":"r1","isSidechain":false,"userType":"external","cwd":"/home/user/app","sessionId":"sess-1","version":"2.1.0","gitBranch":"main","message":{"id":"msg_01Hx","type":"message","role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"tool_use","id":"toolu_02","name":"Read","input":{"file_path":"/home/user/app/main.go"}}],"stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":4,"cache_creation_input_tokens":2048,"cache_read_input_tokens":12000,"cache_creation":{"ephemeral_5m_input_tokens":2048,"ephemeral_1h_input_tokens":0},"output_tokens":245,"service_tier":"standard"}},"requestId":"req_011C","type":"assistant","uuid":"a4","timestamp":"2026-02-25T06:42:01.300Z"}
+{"parentUuid":"a4","isSidechain":false,"userType":"external","cwd":"/home/user/app","sessionId":"sess-1","version":"2.1.0","gitBranch":"main","type":"user","message":{"role":"user","content":[{"tool_use_id":"toolu_02","type":"tool_result","content":"package main"}]},"uuid":"r2","timestamp":"2026-02-25T06:42:02.000Z"}
+{"parentUuid":"r2","isSidechain":false,"userType":"external","cwd":"/home/user/app","sessionId":"sess-1","version":"2.1.0","gitBranch":"main","message":{"id":"msg_02Ab","type":"message","role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"text","text":"Fixed."}],"stop_reason":"end_turn","stop_sequence":null,"usage":{"input_tokens":6,"cache_creation_input_tokens":300,"cache_read_input_tokens":14048,"output_tokens":30,"service_tier":"standard"}},"requestId":"req_011D","type":"assistant","uuid":"a5","timestamp":"2026-02-25T06:42:04.000Z"}
+`
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var uuids []string
+	for _, e := range conv.Entries {
+		uuids = append(uuids, e.UUID)
+	}
+	if got := strings.Join(uuids, ","); got != "u1,a1,r1,r2,a5" {
+		t.Fatalf("Entries = %s, want u1,a1,r1,r2,a5", got)
+	}
+
+	merged := conv.Entries[1]
+	var types []string
+	for _, b := range merged.Message.Content.Blocks {
+		types = append(types, b.BlockType())
+	}
+	if got := strings.Join(types, ","); got != "thinking,text,tool_use,tool_use" {
+		t.Errorf("merged blocks = %s, want thinking,text,tool_use,tool_use", got)
+	}
+	if u := merged.Message.Usage; u.OutputTokens != 245 || u.CacheReadInputTokens != 12000 {
+		t.Errorf("merged usage = %+v, want the final output tokens and one copy of the input", u)
+	}
+
+	if conv.TotalInput != 10 || conv.TotalOutput != 275 || conv.TotalCacheRead != 26048 || conv.TotalCacheCreation != 2348 {
+		t.Errorf("totals = in %d, out %d, cache read %d, cache write %d; want 10, 275, 26048, 2348",
+			conv.TotalInput, conv.TotalOutput, conv.TotalCacheRead, conv.TotalCacheCreation)
+	}
+	for _, uuid := range []string{"a2", "a3", "a4"} {
+		if got := conv.EntryUUID(uuid); got != "a1" {
+			t.Errorf("EntryUUID(%q) = %q, want a1", uuid, got)
+		}
+	}
+	if got := conv.EntryUUID("r1"); got != "r1" {
+		t.Errorf("EntryUUID(r1) = %q, want r1", got)
+	}
+	if call := conv.ToolCalls["toolu_02"]; call.Name != "Read" || call.Outcome != OutcomeSuccess {
+		t.Errorf("ToolCalls[toolu_02] = %+v", call)
+	}
+}
+
+func TestParseSessionFile_Models(t *testing.T) {
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55Z","sessionId":"sess-1","message":{"role":"user","content":"Plan it"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:00Z","sessionId":"sess-1","message":{"id":"msg_1","model":"claude-opus-4-6","role":"assistant","content":[{"type":"text","text":"Plan"}],"usage":{"input_tokens":100,"output_tokens":50,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}
+{"type":"user","uuid":"u2","timestamp":"2026-02-25T06:43:00Z","sessionId":"sess-1","message":{"role":"user","content":"Now do it"}}
+{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T06:43:05Z","sessionId":"sess-1","message":{"id":"msg_2","model":"claude-sonnet-4-5-20250929","role":"assistant","content":[{"type":"text","text":"Done"}],"usage":{"input_tokens":200,"output_tokens":20,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}
+{"type":"assistant","uuid":"a3","timestamp":"2026-02-25T06:43:06Z","sessionId":"sess-1","message":{"id":"msg_3","model":"<synthetic>","role":"assistant","content":[{"type":"text","text":"API Error"}],"usage":{"input_tokens":0,"output_tokens":0,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}
+{"type":"assistant","uuid":"a4","timestamp":"2026-02-25T06:43:10Z","sessionId":"sess-1","message":{"id":"msg_4","model":"claude-sonnet-4-5-20250929","role":"assistant","content":[{"type":"text","text":"Retried"}],"usage":{"input_tokens":300,"output_tokens":30,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}
+`
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(conv.Models) != 2 {
+		t.Fatalf("Models = %+v, want opus and sonnet", conv.Models)
+	}
+	if m := conv.Models[0]; m.Model != "claude-opus-4-6" || m.Messages != 1 || m.Tokens() != 150 {
+		t.Errorf("Models[0] = %+v", m)
+	}
+	if m := conv.Models[1]; m.Model != "claude-sonnet-4-5-20250929" || m.Messages != 2 || m.InputTokens != 500 || m.OutputTokens != 50 {
+		t.Errorf("Models[1] = %+v", m)
+	}
+	for uuid, want := range map[string]bool{"a1": false, "a2": true, "a3": false, "a4": false} {
+		if got := conv.ModelChanged(uuid); got != want {
+			t.Errorf("ModelChanged(%q) = %v, want %v", uuid, got, want)
+		}
+	}
+
+	s := summarize("proj", conv)
+	if s.Model != "claude-opus-4-6" || strings.Join(s.Models, ",") != "claude-opus-4-6,claude-sonnet-4-5-20250929" {
+		t.Errorf("summary Model = %q, Models = %v", s.Model, s.Models)
+	}
+	if got := FilterSessions([]Session{s}, SessionQuery{Model: "sonnet"}); len(got) != 1 {
+		t.Error("model filter should match any model used in the session")
+	}
+}
+
 func TestListProjects(t *testing.T) {
 	dir := t.TempDir()
 
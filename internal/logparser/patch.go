@@ -0,0 +1,218 @@
+package logparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nhosoya/claude-code-share/internal/diff"
+)
+
+// PatchOptions limits a patch to a range of messages. From and To are
+// message UUIDs or 1-based positions in the conversation, both inclusive;
+// empty means unbounded.
+type PatchOptions struct {
+	From, To string
+}
+
+// PatchFile is the net change a session made to one file.
+type PatchFile struct {
+	diff.File
+	Path string // Absolute
+	Rel  string // Relative to the session's working directory
+	// Unanchored counts hunks that could not be placed deterministically,
+	// either because the file's prior content is unknown or because an
+	// edit no longer matched. Their section header says why.
+	Unanchored int
+}
+
+// Patch is the net change of a session as a set of file diffs.
+type Patch struct {
+	Files []PatchFile
+}
+
+// String formats the patch as a multi-file unified diff.
+func (p *Patch) String() string {
+	var b strings.Builder
+	for _, f := range p.Files {
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", f.Rel, f.Rel)
+		// git applies created and deleted files only with their mode.
+		switch {
+		case f.OldName == "/dev/null":
+			b.WriteString("new file mode 100644\n")
+		case f.NewName == "/dev/null":
+			b.WriteString("deleted file mode 100644\n")
+		}
+		b.WriteString(f.File.String())
+	}
+	return b.String()
+}
+
+// Unanchored returns the number of non-deterministic hunks in the patch.
+func (p *Patch) Unanchored() int {
+	n := 0
+	for _, f := range p.Files {
+		n += f.Unanchored
+	}
+	return n
+}
+
+// BuildPatch replays the successful Edit, MultiEdit and Write calls of a
+// conversation into a unified diff per file. A file's starting content is
+// taken from the latest file-history checkpoint before the range, or is
+// empty when the session created the file. Edits that cannot be replayed
+// against a known content are emitted as unanchored hunks.
+func BuildPatch(logDir string, conv *Conversation, opts PatchOptions) (*Patch, error) {
+	start, end, err := conv.messageRange(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	base := conv.baseCWD()
+	checkpoints := loadCheckpoints(logDir, conv)
+	created := make(map[string]bool)
+	for _, f := range SessionFiles(conv) {
+		created[f.Path] = f.Created
+	}
+
+	var order []string
+	byPath := make(map[string][]FileEdit)
+	for _, e := range conv.FileEdits() {
+		if byPath[e.Path] == nil {
+			order = append(order, e.Path)
+		}
+		byPath[e.Path] = append(byPath[e.Path], e)
+	}
+
+	patch := &Patch{}
+	for _, path := range order {
+		var before, within []FileEdit
+		for _, e := range byPath[path] {
+			switch {
+			case e.Index < start:
+				before = append(before, e)
+			case e.Index <= end:
+				within = append(within, e)
+			}
+		}
+		if len(within) == 0 {
+			continue
+		}
+
+		f := PatchFile{Path: path, Rel: relPath(base, path)}
+		orig, exists, known := startingContent(checkpoints[path], created[path], before, start)
+		content := orig
+		var unanchored []diff.Hunk
+		for _, e := range within {
+			if !known {
+				unanchored = append(unanchored, editHunks(e, "prior content unknown")...)
+				continue
+			}
+			next, err := e.Apply(content)
+			if err != nil {
+				unanchored = append(unanchored, editHunks(e, err.Error())...)
+				continue
+			}
+			content = next
+		}
+
+		f.OldName, f.NewName = "a/"+f.Rel, "b/"+f.Rel
+		if known {
+			if !exists {
+				f.OldName = "/dev/null"
+			}
+			f.Hunks = diff.Hunks(orig, content, diff.DefaultContext)
+		}
+		f.Hunks = append(f.Hunks, unanchored...)
+		f.Unanchored = len(unanchored)
+		if len(f.Hunks) > 0 {
+			patch.Files = append(patch.Files, f)
+		}
+	}
+	return patch, nil
+}
+
+// startingContent returns a file's content at entry index start: the latest
+// checkpoint at or before start, or the empty file the session created, with
+// the earlier edits replayed on top. known is false when no such origin exists
+// or an earlier edit could not be replayed.
+func startingContent(cps []checkpoint, created bool, before []FileEdit, start int) (content string, exists, known bool) {
+	from := -1
+	for _, cp := range cps {
+		if cp.after >= 0 && cp.after <= start && cp.Available {
+			content, exists, known, from = cp.Content, cp.Exists, true, cp.after
+		}
+	}
+	if !known {
+		if !created {
+			return "", false, false
+		}
+		// The session created the file, so it started out missing.
+		known = true
+	}
+	for _, e := range before {
+		if e.Index <= from {
+			continue
+		}
+		next, err := e.Apply(content)
+		if err != nil {
+			return "", false, false
+		}
+		content, exists = next, true
+	}
+	return content, exists, known
+}
+
+// editHunks renders a single call as hunks without a position, marked with
+// the reason they could not be anchored.
+func editHunks(e FileEdit, reason string) []diff.Hunk {
+	section := "non-deterministic: " + reason
+	if e.IsWrite() {
+		h := diff.Whole("", e.Content)
+		h.Section = section
+		return []diff.Hunk{h}
+	}
+	hunks := make([]diff.Hunk, 0, len(e.Edits))
+	for _, se := range e.Edits {
+		h := diff.Whole(se.OldString, se.NewString)
+		h.Section = section
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+// messageRange resolves the options to inclusive indexes into Entries.
+func (c *Conversation) messageRange(opts PatchOptions) (start, end int, err error) {
+	start, end = 0, len(c.Entries)-1
+	if opts.From != "" {
+		if start, err = c.messageIndex(opts.From); err != nil {
+			return 0, 0, err
+		}
+	}
+	if opts.To != "" {
+		if end, err = c.messageIndex(opts.To); err != nil {
+			return 0, 0, err
+		}
+	}
+	if start > end {
+		return 0, 0, fmt.Errorf("message range %s..%s is empty", opts.From, opts.To)
+	}
+	return start, end, nil
+}
+
+// messageIndex finds a message by UUID or 1-based position.
+func (c *Conversation) messageIndex(ref string) (int, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(c.Entries) {
+			return 0, fmt.Errorf("message %d out of range 1-%d", n, len(c.Entries))
+		}
+		return n - 1, nil
+	}
+	ref = c.EntryUUID(ref)
+	for i, e := range c.Entries {
+		if e.UUID == ref {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown message %q", ref)
+}
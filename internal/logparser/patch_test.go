@@ -0,0 +1,126 @@
+package logparser
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const patchSession = `{"type":"user","uuid":"u1","timestamp":"2026-02-25T10:00:00Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":"Go"}}
+{"type":"file-history-snapshot","messageId":"u1","snapshot":{"messageId":"u1","trackedFileBackups":{"/work/app/main.go":{"backupFileName":"aaa@v1","version":1,"backupTime":"2026-02-25T10:00:02Z"}},"timestamp":"2026-02-25T10:00:00Z"},"isSnapshotUpdate":true}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T10:00:01Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Edit","input":{"file_path":"/work/app/main.go","old_string":"two","new_string":"2"}},{"type":"tool_use","id":"t2","name":"Write","input":{"file_path":"/work/app/new.go","content":"package main\n"}},{"type":"tool_use","id":"t3","name":"Edit","input":{"file_path":"/work/app/other.go","old_string":"foo()","new_string":"bar()"}},{"type":"tool_use","id":"t4","name":"Edit","input":{"file_path":"/work/app/main.go","old_string":"one","new_string":"uno"}}]}}
+{"type":"user","uuid":"r1","timestamp":"2026-02-25T10:00:03Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"},{"type":"tool_result","tool_use_id":"t2","content":"ok"},{"type":"tool_result","tool_use_id":"t3","content":"ok"},{"type":"tool_result","tool_use_id":"t4","content":"denied","is_error":true}]}}
+{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T10:01:00Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"t5","name":"Edit","input":{"file_path":"/work/app/new.go","old_string":"package main\n","new_string":"package main\n\nfunc f() {}\n"}}]}}
+{"type":"user","uuid":"r2","timestamp":"2026-02-25T10:01:01Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t5","content":"ok"}]}}
+`
+
+func setupPatchSession(t *testing.T) (string, *Conversation) {
+	t.Helper()
+	root := t.TempDir()
+	logDir := filepath.Join(root, "projects")
+	os.MkdirAll(filepath.Join(logDir, "-work-app"), 0755)
+	os.MkdirAll(filepath.Join(root, "file-history", "sess-1"), 0755)
+	os.WriteFile(filepath.Join(root, "file-history", "sess-1", "aaa@v1"), []byte("one\ntwo\nthree\n"), 0644)
+	path := filepath.Join(logDir, "-work-app", "sess-1.jsonl")
+	os.WriteFile(path, []byte(patchSession), 0644)
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logDir, conv
+}
+
+func TestBuildPatch(t *testing.T) {
+	logDir, conv := setupPatchSession(t)
+	patch, err := BuildPatch(logDir, conv, PatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := patch.String()
+
+	wants := []string{
+		// Replayed on the checkpoint; the failed edit of "one" is left out.
+		"diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n",
+		// Created in the session, with the later edit folded in.
+		"--- /dev/null\n+++ b/new.go\n@@ -0,0 +1,3 @@\n+package main\n+\n+func f() {}\n",
+		// No known prior content.
+		"--- a/other.go\n+++ b/other.go\n@@ -1 +1 @@ non-deterministic: prior content unknown\n-foo()\n+bar()\n",
+	}
+	for _, want := range wants {
+		if !strings.Contains(got, want) {
+			t.Errorf("patch missing\n%s\ngot\n%s", want, got)
+		}
+	}
+	if patch.Unanchored() != 1 {
+		t.Errorf("Unanchored = %d, want 1", patch.Unanchored())
+	}
+}
+
+func TestBuildPatch_Range(t *testing.T) {
+	logDir, conv := setupPatchSession(t)
+
+	// Only the last edit, applied on top of the content written earlier.
+	patch, err := BuildPatch(logDir, conv, PatchOptions{From: "a2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patch.Files) != 1 || patch.Files[0].Rel != "new.go" || patch.Files[0].OldName != "a/new.go" {
+		t.Fatalf("patch = %s, want only the new.go edit", patch)
+	}
+	if got := patch.String(); !strings.Contains(got, "@@ -1 +1,3 @@\n package main\n+\n+func f() {}\n") {
+		t.Errorf("patch = %s", got)
+	}
+
+	if _, err := BuildPatch(logDir, conv, PatchOptions{From: "5", To: "2"}); err == nil {
+		t.Error("expected error for an empty range")
+	}
+	if _, err := BuildPatch(logDir, conv, PatchOptions{To: "missing"}); err == nil {
+		t.Error("expected error for an unknown message")
+	}
+}
+
+func TestBuildPatch_NoNewline(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	root := t.TempDir()
+	logDir := filepath.Join(root, "projects")
+	os.MkdirAll(filepath.Join(logDir, "-work-app"), 0755)
+	os.MkdirAll(filepath.Join(root, "file-history", "sess-1"), 0755)
+	// Neither the checkpoint nor the written file ends in a newline.
+	os.WriteFile(filepath.Join(root, "file-history", "sess-1", "aaa@v1"), []byte("one\ntwo"), 0644)
+	session := strings.ReplaceAll(patchSession, `"content":"package main\n"`, `"content":"package main"`)
+	session = strings.ReplaceAll(session, `"old_string":"two","new_string":"2"`, `"old_string":"two","new_string":"2\n"`)
+	session = strings.ReplaceAll(session, `"old_string":"package main\n","new_string":"package main\n\nfunc f() {}\n"`, `"old_string":"package main","new_string":"package main\n\nfunc f() {}"`)
+	path := filepath.Join(logDir, "-work-app", "sess-1.jsonl")
+	os.WriteFile(path, []byte(session), 0644)
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := BuildPatch(logDir, conv, PatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Leave out the unanchored other.go hunk, which git cannot apply.
+	patch.Files = slices.DeleteFunc(patch.Files, func(f PatchFile) bool { return f.Unanchored > 0 })
+	got := patch.String()
+	if want := "-two\n\\ No newline at end of file\n+2\n"; !strings.Contains(got, want) {
+		t.Errorf("patch missing\n%s\ngot\n%s", want, got)
+	}
+	if want := "+func f() {}\n\\ No newline at end of file\n"; !strings.Contains(got, want) {
+		t.Errorf("patch missing\n%s\ngot\n%s", want, got)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("one\ntwo"), 0644)
+	cmd := exec.Command("git", "apply", "--check", "-")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(got)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("git apply --check: %v\n%s\npatch:\n%s", err, out, got)
+	}
+}
@@ -0,0 +1,41 @@
+package logparser
+
+import "strings"
+
+// modelPrice is the list price of a model in USD per million tokens.
+type modelPrice struct {
+	Input, Output, CacheWrite, CacheRead float64
+}
+
+// modelPrices maps a model name fragment to its price. The first matching
+// fragment wins, so more specific fragments come first.
+var modelPrices = []struct {
+	fragment string
+	price    modelPrice
+}{
+	{"opus-4-6", modelPrice{5, 25, 6.25, 0.50}},
+	{"opus-4-5", modelPrice{5, 25, 6.25, 0.50}},
+	{"opus", modelPrice{15, 75, 18.75, 1.50}},
+	{"sonnet", modelPrice{3, 15, 3.75, 0.30}},
+	{"haiku-3", modelPrice{0.80, 4, 1, 0.08}},
+	{"haiku", modelPrice{1, 5, 1.25, 0.10}},
+}
+
+// EstimateCost returns the approximate USD cost of a single API call.
+// Unknown models are priced at zero.
+func EstimateCost(model string, u *Usage) float64 {
+	if u == nil {
+		return 0
+	}
+	for _, mp := range modelPrices {
+		if !strings.Contains(model, mp.fragment) {
+			continue
+		}
+		p := mp.price
+		return (float64(u.InputTokens)*p.Input +
+			float64(u.OutputTokens)*p.Output +
+			float64(u.CacheCreationInputTokens)*p.CacheWrite +
+			float64(u.CacheReadInputTokens)*p.CacheRead) / 1e6
+	}
+	return 0
+}
@@ -0,0 +1,254 @@
+package logparser
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sort keys accepted by ProjectQuery and SessionQuery.
+const (
+	SortActivity = "activity"
+	SortSessions = "sessions"
+	SortMessages = "messages"
+	SortTokens   = "tokens"
+	SortCost     = "cost"
+	SortName     = "name"
+	SortFailures = "failures"
+)
+
+// ProjectQuery filters and orders the project list.
+type ProjectQuery struct {
+	Sort         string // One of SortActivity (default), SortSessions, SortTokens, SortCost, SortName
+	Ascending    bool
+	Model        string // Case-insensitive substring of any model used
+	Since, Until time.Time
+	PathContains string // Case-insensitive substring of the decoded path
+	MinMessages  int
+	WithStats    bool // Compute aggregates even when not needed to filter or sort
+}
+
+// SessionQuery filters and orders a project's session list.
+type SessionQuery struct {
+	Sort         string // One of SortActivity (default), SortMessages, SortTokens, SortCost, SortFailures
+	Ascending    bool
+	Model        string // Case-insensitive substring of the session model
+	Branch       string // Exact git branch seen during the session
+	Since, Until time.Time
+	MinMessages  int
+}
+
+// needsStats reports whether the query depends on per-project aggregates,
+// which require parsing every session.
+func (q ProjectQuery) needsStats() bool {
+	if q.WithStats {
+		return true
+	}
+	switch q.Sort {
+	case SortTokens, SortCost:
+		return true
+	}
+	return q.Model != "" || q.MinMessages > 0
+}
+
+// QueryProjects lists projects matching q in the requested order.
+// It gives up once ctx is done.
+func QueryProjects(ctx context.Context, logDir string, q ProjectQuery) ([]Project, error) {
+	return queryProjects(ctx, logDir, q, ListSessionsContext)
+}
+
+// sessionLister lists the sessions of a project.
+type sessionLister func(ctx context.Context, logDir, slug string) ([]Session, error)
+
+func queryProjects(ctx context.Context, logDir string, q ProjectQuery, list sessionLister) ([]Project, error) {
+	projects, err := ListProjectsContext(ctx, logDir)
+	if err != nil {
+		return nil, err
+	}
+	if q.needsStats() {
+		for i := range projects {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			loadProjectStats(ctx, logDir, &projects[i], list)
+		}
+	}
+
+	filtered := projects[:0]
+	for _, p := range projects {
+		if q.PathContains != "" && !containsFold(p.Path, q.PathContains) {
+			continue
+		}
+		if !inRange(p.LastActivity, q.Since, q.Until) {
+			continue
+		}
+		if q.Model != "" && !anyContainsFold(p.Models, q.Model) {
+			continue
+		}
+		if p.MessageCount < q.MinMessages {
+			continue
+		}
+		filtered = append(filtered, p)
+	}
+
+	less := func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+		switch q.Sort {
+		case SortSessions:
+			return a.SessionCount < b.SessionCount
+		case SortTokens:
+			return a.Tokens < b.Tokens
+		case SortCost:
+			return a.Cost < b.Cost
+		case SortName:
+			return a.Path < b.Path
+		default:
+			return a.LastActivity.Before(b.LastActivity)
+		}
+	}
+	sortDir(filtered, less, q.Ascending)
+	return filtered, nil
+}
+
+// loadProjectStats lists every session in p and fills its aggregate fields.
+func loadProjectStats(ctx context.Context, logDir string, p *Project, list sessionLister) {
+	sessions, err := list(ctx, logDir, p.Slug)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("failed to load project stats", "error", err, "slug", p.Slug)
+		}
+		return
+	}
+	seen := make(map[string]bool)
+	for _, s := range sessions {
+		p.MessageCount += s.MessageCount
+		p.Tokens += s.Tokens()
+		p.Cost += s.Cost
+		for _, m := range s.allModels() {
+			if !seen[m] {
+				seen[m] = true
+				p.Models = append(p.Models, m)
+			}
+		}
+	}
+	sort.Strings(p.Models)
+	p.HasStats = true
+}
+
+// QuerySessions lists a project's sessions matching q in the requested order.
+// It gives up once ctx is done.
+func QuerySessions(ctx context.Context, logDir, slug string, q SessionQuery) ([]Session, error) {
+	sessions, err := ListSessionsContext(ctx, logDir, slug)
+	if err != nil {
+		return nil, err
+	}
+	return FilterSessions(sessions, q), nil
+}
+
+// FilterSessions applies q to an already loaded session list.
+func FilterSessions(sessions []Session, q SessionQuery) []Session {
+	filtered := sessions[:0]
+	for _, s := range sessions {
+		if q.Model != "" && !anyContainsFold(s.allModels(), q.Model) {
+			continue
+		}
+		if q.Branch != "" && !slices.Contains(s.Branches, q.Branch) {
+			continue
+		}
+		if !inRange(s.Timestamp, q.Since, q.Until) {
+			continue
+		}
+		if s.MessageCount < q.MinMessages {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+
+	less := func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+		switch q.Sort {
+		case SortMessages:
+			return a.MessageCount < b.MessageCount
+		case SortTokens:
+			return a.Tokens() < b.Tokens()
+		case SortCost:
+			return a.Cost < b.Cost
+		case SortFailures:
+			return a.Failures.Total()+a.Interrupts < b.Failures.Total()+b.Interrupts
+		default:
+			return a.Timestamp.Before(b.Timestamp)
+		}
+	}
+	sortDir(filtered, less, q.Ascending)
+	return filtered
+}
+
+// Page is one page of a paginated list.
+type Page[T any] struct {
+	Items   []T `json:"items"`
+	Total   int `json:"total"`
+	Page    int `json:"page"`
+	PerPage int `json:"perPage"`
+	Pages   int `json:"pages"`
+}
+
+// Paginate returns the 1-based page of items. Out-of-range pages are clamped.
+func Paginate[T any](items []T, page, perPage int) Page[T] {
+	if perPage <= 0 {
+		perPage = len(items)
+	}
+	pages := 1
+	if perPage > 0 && len(items) > 0 {
+		pages = (len(items) + perPage - 1) / perPage
+	}
+	page = max(1, min(page, pages))
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+	pageItems := items[start:end]
+	if pageItems == nil {
+		pageItems = []T{}
+	}
+	return Page[T]{
+		Items:   pageItems,
+		Total:   len(items),
+		Page:    page,
+		PerPage: perPage,
+		Pages:   pages,
+	}
+}
+
+// sortDir stably sorts s with less, reversing the order unless ascending.
+func sortDir[T any](s []T, less func(i, j int) bool, ascending bool) {
+	if ascending {
+		sort.SliceStable(s, less)
+		return
+	}
+	sort.SliceStable(s, func(i, j int) bool { return less(j, i) })
+}
+
+func inRange(t, since, until time.Time) bool {
+	if !since.IsZero() && t.Before(since) {
+		return false
+	}
+	if !until.IsZero() && !t.Before(until) {
+		return false
+	}
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func anyContainsFold(list []string, substr string) bool {
+	for _, s := range list {
+		if containsFold(s, substr) {
+			return true
+		}
+	}
+	return false
+}
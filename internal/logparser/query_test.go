@@ -0,0 +1,157 @@
+package logparser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueryProjects(t *testing.T) {
+	dir := t.TempDir()
+	api := filepath.Join(dir, "-Users-foo-workspace-api")
+	web := filepath.Join(dir, "-Users-foo-workspace-web")
+	os.MkdirAll(api, 0755)
+	os.MkdirAll(web, 0755)
+	writeTestSession(t, api, "sess-a.jsonl", "2026-02-24T10:00:00.000Z", "First")
+	writeTestSession(t, api, "sess-b.jsonl", "2026-02-24T12:00:00.000Z", "Second")
+	writeTestSession(t, web, "sess-c.jsonl", "2026-02-25T06:00:00.000Z", "Third")
+
+	tests := []struct {
+		name string
+		q    ProjectQuery
+		want []string
+	}{
+		{"default newest first", ProjectQuery{}, []string{"-Users-foo-workspace-web", "-Users-foo-workspace-api"}},
+		{"by sessions", ProjectQuery{Sort: SortSessions}, []string{"-Users-foo-workspace-api", "-Users-foo-workspace-web"}},
+		{"by name ascending", ProjectQuery{Sort: SortName, Ascending: true}, []string{"-Users-foo-workspace-api", "-Users-foo-workspace-web"}},
+		{"by tokens", ProjectQuery{Sort: SortTokens}, []string{"-Users-foo-workspace-api", "-Users-foo-workspace-web"}},
+		{"path filter", ProjectQuery{PathContains: "WEB"}, []string{"-Users-foo-workspace-web"}},
+		{"model filter", ProjectQuery{Model: "sonnet"}, nil},
+		{"min messages", ProjectQuery{MinMessages: 3}, []string{"-Users-foo-workspace-api"}},
+		{"date range", ProjectQuery{Until: time.Date(2026, 2, 25, 0, 0, 0, 0, time.UTC)}, []string{"-Users-foo-workspace-api"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projects, err := QueryProjects(context.Background(), dir, tt.q)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(projects) != len(tt.want) {
+				t.Fatalf("projects length = %d, want %d", len(projects), len(tt.want))
+			}
+			for i, slug := range tt.want {
+				if projects[i].Slug != slug {
+					t.Errorf("projects[%d].Slug = %q, want %q", i, projects[i].Slug, slug)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryProjects_Stats(t *testing.T) {
+	dir := t.TempDir()
+	proj := filepath.Join(dir, "-Users-foo-workspace-api")
+	os.MkdirAll(proj, 0755)
+	writeTestSession(t, proj, "sess-a.jsonl", "2026-02-24T10:00:00.000Z", "First")
+	writeTestSession(t, proj, "sess-b.jsonl", "2026-02-24T12:00:00.000Z", "Second")
+
+	projects, err := QueryProjects(context.Background(), dir, ProjectQuery{Sort: SortCost})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := projects[0]
+	if !p.HasStats {
+		t.Fatal("HasStats should be true when sorting by cost")
+	}
+	if p.MessageCount != 4 || p.Tokens != 30 {
+		t.Errorf("MessageCount = %d, Tokens = %d, want 4, 30", p.MessageCount, p.Tokens)
+	}
+	if len(p.Models) != 1 || p.Models[0] != "claude-opus-4-6" {
+		t.Errorf("Models = %v, want [claude-opus-4-6]", p.Models)
+	}
+	if p.Cost <= 0 {
+		t.Errorf("Cost = %v, want > 0", p.Cost)
+	}
+}
+
+func TestFilterSessions(t *testing.T) {
+	ts := func(s string) time.Time {
+		v, _ := time.Parse(time.RFC3339, s)
+		return v
+	}
+	sessions := []Session{
+		{ID: "a", Timestamp: ts("2026-02-24T10:00:00Z"), MessageCount: 10, Model: "claude-opus-4-6", InputTokens: 5, Branches: []string{"main", "fix/login"}},
+		{ID: "b", Timestamp: ts("2026-02-25T10:00:00Z"), MessageCount: 2, Model: "claude-sonnet-4-6", InputTokens: 50},
+		{ID: "c", Timestamp: ts("2026-02-26T10:00:00Z"), MessageCount: 5, Model: "claude-opus-4-6", InputTokens: 20},
+	}
+
+	got := FilterSessions(append([]Session(nil), sessions...), SessionQuery{Sort: SortTokens})
+	if got[0].ID != "b" || got[2].ID != "a" {
+		t.Errorf("tokens order = %s,%s,%s, want b,c,a", got[0].ID, got[1].ID, got[2].ID)
+	}
+
+	got = FilterSessions(append([]Session(nil), sessions...), SessionQuery{Model: "OPUS", Ascending: true})
+	if len(got) != 2 || got[0].ID != "a" {
+		t.Errorf("opus ascending = %v, want a then c", got)
+	}
+
+	got = FilterSessions(append([]Session(nil), sessions...), SessionQuery{MinMessages: 5, Since: ts("2026-02-25T00:00:00Z")})
+	if len(got) != 1 || got[0].ID != "c" {
+		t.Errorf("min messages + since = %v, want only c", got)
+	}
+
+	got = FilterSessions(append([]Session(nil), sessions...), SessionQuery{Branch: "fix/login"})
+	if len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("branch = %v, want only a", got)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		page, perPage int
+		want          []int
+		pages         int
+	}{
+		{1, 2, []int{1, 2}, 3},
+		{3, 2, []int{5}, 3},
+		{9, 2, []int{5}, 3}, // clamped to the last page
+		{0, 10, []int{1, 2, 3, 4, 5}, 1},
+	}
+	for _, tt := range tests {
+		p := Paginate(items, tt.page, tt.perPage)
+		if len(p.Items) != len(tt.want) || p.Pages != tt.pages || p.Total != 5 {
+			t.Errorf("Paginate(page=%d, perPage=%d) = %+v, want items %v over %d pages", tt.page, tt.perPage, p, tt.want, tt.pages)
+			continue
+		}
+		for i := range tt.want {
+			if p.Items[i] != tt.want[i] {
+				t.Errorf("Paginate(page=%d, perPage=%d).Items = %v, want %v", tt.page, tt.perPage, p.Items, tt.want)
+				break
+			}
+		}
+	}
+
+	empty := Paginate([]int(nil), 1, 10)
+	if empty.Items == nil || empty.Pages != 1 {
+		t.Errorf("Paginate(nil) = %+v, want empty non-nil items on 1 page", empty)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	u := &Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000}
+	if got := EstimateCost("claude-sonnet-4-6", u); got != 18 {
+		t.Errorf("sonnet cost = %v, want 18", got)
+	}
+	if got := EstimateCost("claude-opus-4-6", u); got != 30 {
+		t.Errorf("opus 4.6 cost = %v, want 30", got)
+	}
+	if got := EstimateCost("unknown-model", u); got != 0 {
+		t.Errorf("unknown model cost = %v, want 0", got)
+	}
+	if got := EstimateCost("claude-sonnet-4-6", nil); got != 0 {
+		t.Errorf("nil usage cost = %v, want 0", got)
+	}
+}
@@ -0,0 +1,117 @@
+package logparser
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// Errors returned by FileEdit.Apply when an edit cannot be replayed
+// deterministically against the given content.
+var (
+	ErrEditNotFound  = errors.New("old_string not found")
+	ErrEditAmbiguous = errors.New("old_string is not unique")
+)
+
+// StringEdit is a single old_string/new_string replacement.
+type StringEdit struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// FileEdit is a successful Edit, MultiEdit or Write tool call.
+type FileEdit struct {
+	Tool      string
+	Path      string // Absolute
+	EntryUUID string // Assistant entry holding the tool call
+	Index     int    // Position of that entry in Conversation.Entries
+	Timestamp time.Time
+	Edits     []StringEdit // Edit and MultiEdit
+	Content   string       // Write
+}
+
+// IsWrite reports whether the call replaces the whole file.
+func (e FileEdit) IsWrite() bool {
+	return e.Tool == "Write"
+}
+
+// Apply replays the call on content, following the rules Claude Code
+// enforces: old_string must occur exactly once unless replace_all is set,
+// and an empty old_string creates an empty file.
+func (e FileEdit) Apply(content string) (string, error) {
+	if e.IsWrite() {
+		return e.Content, nil
+	}
+	for _, se := range e.Edits {
+		if se.OldString == "" {
+			if content != "" {
+				return content, ErrEditAmbiguous
+			}
+			content = se.NewString
+			continue
+		}
+		switch n := strings.Count(content, se.OldString); {
+		case n == 0:
+			return content, ErrEditNotFound
+		case n > 1 && !se.ReplaceAll:
+			return content, ErrEditAmbiguous
+		}
+		content = strings.ReplaceAll(content, se.OldString, se.NewString)
+	}
+	return content, nil
+}
+
+// FileEdits returns the file modifications of the conversation whose tool
+// results reported success, in log order. Calls without a result, such as
+// those interrupted at the end of a session, are left out.
+func (c *Conversation) FileEdits() []FileEdit {
+	if c.ToolCalls == nil {
+		c.classifyToolCalls()
+	}
+	base := c.baseCWD()
+
+	var edits []FileEdit
+	for i, e := range c.Entries {
+		if e.Type != "assistant" {
+			continue
+		}
+		cwd := e.CWD
+		if cwd == "" {
+			cwd = base
+		}
+		for _, b := range e.Message.Content.Blocks {
+			u, ok := b.(*claudelog.ToolUseBlock)
+			if !ok || c.ToolCalls[u.ID].Outcome != OutcomeSuccess {
+				continue
+			}
+			fe, valid := parseFileEdit(u.Name, ParseToolInput(u.Input))
+			if !valid {
+				continue
+			}
+			fe.Path = absPath(cwd, fe.Path)
+			fe.EntryUUID = e.UUID
+			fe.Index = i
+			fe.Timestamp = e.Timestamp
+			edits = append(edits, fe)
+		}
+	}
+	return edits
+}
+
+func parseFileEdit(name string, input ToolInput) (FileEdit, bool) {
+	fe := FileEdit{Tool: name, Path: input.FilePath}
+	switch name {
+	case "Write":
+		fe.Content = input.Content
+	case "Edit":
+		fe.Edits = []StringEdit{{OldString: input.OldString, NewString: input.NewString, ReplaceAll: input.ReplaceAll}}
+	case "MultiEdit":
+		fe.Edits = input.Edits
+	default:
+		return FileEdit{}, false
+	}
+	return fe, fe.Path != ""
+}
@@ -0,0 +1,69 @@
+package logparser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFileEditApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    FileEdit
+		in      string
+		want    string
+		wantErr error
+	}{
+		{"write", FileEdit{Tool: "Write", Content: "new"}, "old", "new", nil},
+		{"edit", FileEdit{Tool: "Edit", Edits: []StringEdit{{OldString: "a", NewString: "b"}}}, "xay", "xby", nil},
+		{"not found", FileEdit{Tool: "Edit", Edits: []StringEdit{{OldString: "z", NewString: "b"}}}, "xay", "xay", ErrEditNotFound},
+		{"ambiguous", FileEdit{Tool: "Edit", Edits: []StringEdit{{OldString: "a", NewString: "b"}}}, "aa", "aa", ErrEditAmbiguous},
+		{"replace all", FileEdit{Tool: "Edit", Edits: []StringEdit{{OldString: "a", NewString: "b", ReplaceAll: true}}}, "aa", "bb", nil},
+		{"create", FileEdit{Tool: "Edit", Edits: []StringEdit{{NewString: "hi"}}}, "", "hi", nil},
+		{"multi", FileEdit{Tool: "MultiEdit", Edits: []StringEdit{
+			{OldString: "one", NewString: "1"},
+			{OldString: "1 two", NewString: "1 2"},
+		}}, "one two", "1 2", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.edit.Apply(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("Apply = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileEdits(t *testing.T) {
+	line := `{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T10:00:00Z","sessionId":"s","cwd":"/work","message":{"role":"assistant","content":[` +
+		`{"type":"tool_use","id":"t1","name":"Edit","input":{"file_path":"main.go","old_string":"a","new_string":"b"}},` +
+		`{"type":"tool_use","id":"t2","name":"MultiEdit","input":{"file_path":"/work/x.go","edits":[{"old_string":"c","new_string":"d","replace_all":true}]}},` +
+		`{"type":"tool_use","id":"t3","name":"Write","input":{"file_path":"/work/y.go","content":"y"}},` +
+		`{"type":"tool_use","id":"t4","name":"Write","input":{"file_path":"/work/z.go","content":"z"}}]}}`
+	results := `{"type":"user","uuid":"u2","timestamp":"2026-02-25T10:00:01Z","sessionId":"s","message":{"role":"user","content":[` +
+		`{"type":"tool_result","tool_use_id":"t1","content":"ok"},` +
+		`{"type":"tool_result","tool_use_id":"t2","content":"ok"},` +
+		`{"type":"tool_result","tool_use_id":"t3","content":"<tool_use_error>File has not been read yet</tool_use_error>","is_error":true}]}}`
+	conv := &Conversation{CWDs: []string{"/work"}}
+	for _, l := range []string{line, results} {
+		e, err := ParseEntry([]byte(l))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conv.Entries = append(conv.Entries, e)
+	}
+
+	edits := conv.FileEdits()
+	if len(edits) != 2 {
+		t.Fatalf("FileEdits = %+v, want the 2 successful calls", edits)
+	}
+	if edits[0].Path != "/work/main.go" || edits[0].Edits[0].NewString != "b" {
+		t.Errorf("edits[0] = %+v, want Edit of /work/main.go", edits[0])
+	}
+	if edits[1].Tool != "MultiEdit" || !edits[1].Edits[0].ReplaceAll {
+		t.Errorf("edits[1] = %+v, want MultiEdit with replace_all", edits[1])
+	}
+}
@@ -0,0 +1,171 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Repository groups the projects that belong to the same git repository,
+// including its linked worktrees and subdirectories.
+type Repository struct {
+	Root     string    `json:"root"` // Main worktree root, or the project path outside git
+	Name     string    `json:"name"`
+	IsGit    bool      `json:"isGit"`
+	Projects []Project `json:"projects"`
+
+	SessionCount int       `json:"sessionCount"`
+	LastActivity time.Time `json:"lastActivity"`
+	// Aggregates of the project stats; only meaningful when HasStats is true.
+	HasStats     bool    `json:"hasStats"`
+	MessageCount int     `json:"messageCount,omitempty"`
+	Tokens       int     `json:"tokens,omitempty"`
+	Cost         float64 `json:"cost,omitempty"`
+}
+
+// repoInfo is the result of locating the repository containing a path.
+type repoInfo struct {
+	Root     string
+	Worktree bool
+	OK       bool
+}
+
+var repoCache sync.Map // path -> repoInfo
+
+// FindRepoRoot returns the root of the main worktree of the git repository
+// containing path. Linked worktrees (whose .git is a `gitdir:` file pointing
+// into another repository's .git/worktrees) resolve to the main worktree
+// and report worktree as true. ok is false when path is not inside a git
+// repository on this machine.
+func FindRepoRoot(path string) (root string, worktree bool, ok bool) {
+	if v, found := repoCache.Load(path); found {
+		ri := v.(repoInfo)
+		return ri.Root, ri.Worktree, ri.OK
+	}
+	ri := findRepoRoot(path)
+	repoCache.Store(path, ri)
+	return ri.Root, ri.Worktree, ri.OK
+}
+
+func findRepoRoot(path string) repoInfo {
+	if path == "" || !filepath.IsAbs(path) {
+		return repoInfo{}
+	}
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		info, err := os.Stat(filepath.Join(p, ".git"))
+		if err == nil {
+			if info.IsDir() {
+				return repoInfo{Root: p, OK: true}
+			}
+			if root, ok := worktreeRoot(p); ok {
+				return repoInfo{Root: root, Worktree: root != p, OK: true}
+			}
+			return repoInfo{Root: p, OK: true}
+		}
+		if p == filepath.Dir(p) {
+			return repoInfo{}
+		}
+	}
+}
+
+// worktreeRoot follows the `gitdir:` file at dir/.git to the main worktree.
+// Submodules also use a gitdir file but have no commondir, so they are
+// treated as repositories of their own.
+func worktreeRoot(dir string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return "", false
+	}
+	gitdir, found := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !found {
+		return "", false
+	}
+	gitdir = strings.TrimSpace(gitdir)
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(dir, gitdir)
+	}
+
+	common, err := os.ReadFile(filepath.Join(gitdir, "commondir"))
+	if err != nil {
+		return dir, true
+	}
+	commonDir := strings.TrimSpace(string(common))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitdir, commonDir)
+	}
+	return filepath.Dir(filepath.Clean(commonDir)), true
+}
+
+// setRepo fills the repository fields of p from its path.
+func (p *Project) setRepo() {
+	root, worktree, ok := FindRepoRoot(p.Path)
+	if !ok {
+		root = p.Path
+	}
+	p.RepoRoot = root
+	p.InGit = ok
+	p.Worktree = worktree
+}
+
+// GroupProjects groups projects by the git repository their path belongs
+// to. Projects outside git form a group of their own. Groups keep the order
+// in which their first project appears, so the caller's sort order is
+// preserved.
+func GroupProjects(projects []Project) []Repository {
+	byRoot := make(map[string]*Repository)
+	var order []string
+	for _, p := range projects {
+		if p.RepoRoot == "" {
+			p.setRepo()
+		}
+		root := p.RepoRoot
+
+		repo := byRoot[root]
+		if repo == nil {
+			repo = &Repository{Root: root, Name: filepath.Base(root), IsGit: p.InGit, HasStats: true}
+			byRoot[root] = repo
+			order = append(order, root)
+		}
+		repo.Projects = append(repo.Projects, p)
+		repo.SessionCount += p.SessionCount
+		if p.LastActivity.After(repo.LastActivity) {
+			repo.LastActivity = p.LastActivity
+		}
+		repo.HasStats = repo.HasStats && p.HasStats
+		repo.MessageCount += p.MessageCount
+		repo.Tokens += p.Tokens
+		repo.Cost += p.Cost
+	}
+
+	repos := make([]Repository, 0, len(order))
+	for _, root := range order {
+		repo := byRoot[root]
+		sort.SliceStable(repo.Projects, func(i, j int) bool {
+			return repo.Projects[i].Path < repo.Projects[j].Path
+		})
+		repos = append(repos, *repo)
+	}
+	return repos
+}
+
+// Single reports whether the group is a lone project at the repository root,
+// which needs no tree to display.
+func (r Repository) Single() bool {
+	return len(r.Projects) == 1 && r.Projects[0].Path == r.Root
+}
+
+// RelPath returns the project's path relative to its repository root, or
+// "." for the root itself.
+func (p Project) RelPath() string {
+	if p.RepoRoot == "" {
+		return "."
+	}
+	rel, err := filepath.Rel(p.RepoRoot, p.Path)
+	if err != nil {
+		return p.Path
+	}
+	return rel
+}
@@ -0,0 +1,99 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// setupRepos creates a main repository with a linked worktree and a
+// submodule, plus a plain directory outside git.
+func setupRepos(t *testing.T) (main, worktree, sub, plain string) {
+	t.Helper()
+	root := t.TempDir()
+	main = filepath.Join(root, "app")
+	worktree = filepath.Join(root, "app-feature")
+	sub = filepath.Join(main, "vendor", "lib")
+	plain = filepath.Join(root, "scratch")
+
+	os.MkdirAll(filepath.Join(main, ".git", "worktrees", "app-feature"), 0755)
+	os.MkdirAll(filepath.Join(main, ".git", "modules", "lib"), 0755)
+	os.MkdirAll(filepath.Join(main, "cmd", "server"), 0755)
+	os.MkdirAll(worktree, 0755)
+	os.MkdirAll(sub, 0755)
+	os.MkdirAll(plain, 0755)
+
+	os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+filepath.Join(main, ".git", "worktrees", "app-feature")+"\n"), 0644)
+	os.WriteFile(filepath.Join(main, ".git", "worktrees", "app-feature", "commondir"), []byte("../..\n"), 0644)
+	os.WriteFile(filepath.Join(sub, ".git"), []byte("gitdir: ../../.git/modules/lib\n"), 0644)
+	return main, worktree, sub, plain
+}
+
+func TestFindRepoRoot(t *testing.T) {
+	main, worktree, sub, plain := setupRepos(t)
+
+	tests := []struct {
+		name         string
+		path         string
+		wantRoot     string
+		wantWorktree bool
+		wantOK       bool
+	}{
+		{"main root", main, main, false, true},
+		{"subdirectory", filepath.Join(main, "cmd", "server"), main, false, true},
+		{"linked worktree", worktree, main, true, true},
+		{"submodule", sub, sub, false, true},
+		{"outside git", plain, "", false, false},
+		{"relative path", "app", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, wt, ok := FindRepoRoot(tt.path)
+			if root != tt.wantRoot || wt != tt.wantWorktree || ok != tt.wantOK {
+				t.Errorf("FindRepoRoot(%q) = (%q, %v, %v), want (%q, %v, %v)",
+					tt.path, root, wt, ok, tt.wantRoot, tt.wantWorktree, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGroupProjects(t *testing.T) {
+	main, worktree, _, plain := setupRepos(t)
+	now := time.Now()
+
+	projects := []Project{
+		{Slug: "wt", Path: worktree, SessionCount: 2, LastActivity: now},
+		{Slug: "plain", Path: plain, SessionCount: 1, LastActivity: now.Add(-time.Hour)},
+		{Slug: "main", Path: main, SessionCount: 3, LastActivity: now.Add(-2 * time.Hour)},
+		{Slug: "cmd", Path: filepath.Join(main, "cmd", "server"), SessionCount: 1, LastActivity: now.Add(-3 * time.Hour)},
+	}
+	repos := GroupProjects(projects)
+	if len(repos) != 2 {
+		t.Fatalf("repos length = %d, want 2", len(repos))
+	}
+
+	app := repos[0]
+	if app.Root != main || !app.IsGit || app.Name != "app" {
+		t.Errorf("repos[0] = %+v, want git repo rooted at %q", app, main)
+	}
+	if len(app.Projects) != 3 || app.SessionCount != 6 || !app.LastActivity.Equal(now) {
+		t.Errorf("repos[0] has %d projects, %d sessions, last %v; want 3, 6, %v",
+			len(app.Projects), app.SessionCount, app.LastActivity, now)
+	}
+	if app.Single() {
+		t.Error("repo with several projects should not be Single")
+	}
+	for _, p := range app.Projects {
+		if p.Slug == "cmd" && p.RelPath() != filepath.Join("cmd", "server") {
+			t.Errorf("RelPath = %q, want %q", p.RelPath(), filepath.Join("cmd", "server"))
+		}
+		if p.Slug == "wt" && !p.Worktree {
+			t.Error("worktree project should have Worktree set")
+		}
+	}
+
+	if repos[1].IsGit || !repos[1].Single() {
+		t.Errorf("repos[1] = %+v, want a single non-git project", repos[1])
+	}
+}
@@ -0,0 +1,135 @@
+package logparser
+
+import (
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// Where a search match was found.
+const (
+	MatchText       = "text"        // User or assistant message text
+	MatchToolInput  = "tool_input"  // Input of a tool call
+	MatchToolResult = "tool_result" // Output of a tool call
+)
+
+// SearchOptions controls Search.
+type SearchOptions struct {
+	Project string // Only search this project slug
+	Tools   bool   // Also search tool inputs and results
+	Limit   int    // Stop after this many matches; 0 means no limit
+}
+
+// SearchMatch is a line of a message that matches a search pattern.
+type SearchMatch struct {
+	Slug      string    `json:"slug"`
+	SessionID string    `json:"sessionId"`
+	UUID      string    `json:"uuid"`
+	Role      string    `json:"role"` // Entry type: user or assistant
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"` // MatchText, MatchToolInput or MatchToolResult
+	Line      string    `json:"line"`
+	// Spans are the [start, end) byte offsets of the matches in Line.
+	Spans [][2]int `json:"spans"`
+}
+
+// Search finds the lines of messages matching re across all projects,
+// most recently active projects first and sessions in chronological order.
+func Search(logDir string, re *regexp.Regexp, opts SearchOptions) ([]SearchMatch, error) {
+	var slugs []string
+	if opts.Project != "" {
+		slugs = []string{opts.Project}
+	} else {
+		projects, err := ListProjects(logDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			slugs = append(slugs, p.Slug)
+		}
+	}
+
+	var matches []SearchMatch
+	for _, slug := range slugs {
+		files, err := claudelog.Walker{Root: logDir}.Sessions(slug)
+		if err != nil {
+			return nil, err
+		}
+		var convs []*Conversation
+		for _, f := range files {
+			conv, err := ParseSessionFile(f.Path)
+			if err != nil {
+				slog.Warn("skipping session file", "error", err, "file", f.Path)
+				continue
+			}
+			convs = append(convs, conv)
+		}
+		sort.SliceStable(convs, func(i, j int) bool {
+			si, _ := convs[i].TimeRange()
+			sj, _ := convs[j].TimeRange()
+			return si.Before(sj)
+		})
+
+		for _, conv := range convs {
+			for _, m := range searchConversation(conv, re, opts.Tools) {
+				m.Slug = slug
+				matches = append(matches, m)
+				if opts.Limit > 0 && len(matches) >= opts.Limit {
+					return matches, nil
+				}
+			}
+		}
+	}
+	return matches, nil
+}
+
+// searchConversation returns the matching lines of a single conversation.
+func searchConversation(conv *Conversation, re *regexp.Regexp, tools bool) []SearchMatch {
+	var matches []SearchMatch
+	for _, e := range conv.Entries {
+		if e.Type != "user" && e.Type != "assistant" {
+			continue
+		}
+		add := func(source, text string) {
+			for _, line := range strings.Split(text, "\n") {
+				locs := re.FindAllStringIndex(line, -1)
+				if locs == nil {
+					continue
+				}
+				m := SearchMatch{
+					SessionID: conv.SessionID,
+					UUID:      e.UUID,
+					Role:      e.Type,
+					Timestamp: e.Timestamp,
+					Source:    source,
+					Line:      line,
+				}
+				for _, loc := range locs {
+					m.Spans = append(m.Spans, [2]int{loc[0], loc[1]})
+				}
+				matches = append(matches, m)
+			}
+		}
+
+		add(MatchText, e.Message.Content.Text)
+		for _, b := range e.Message.Content.Blocks {
+			switch b := b.(type) {
+			case *claudelog.TextBlock:
+				add(MatchText, b.Text)
+			case *claudelog.ToolUseBlock:
+				if tools {
+					add(MatchToolInput, string(b.Input))
+				}
+			case *claudelog.ToolResultBlock:
+				if tools {
+					add(MatchToolResult, ResultText(b.Content))
+				}
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,66 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	api := filepath.Join(dir, "-work-api")
+	web := filepath.Join(dir, "-work-web")
+	os.MkdirAll(api, 0755)
+	os.MkdirAll(web, 0755)
+	writeTestSession(t, api, "sess-a.jsonl", "2026-02-24T10:00:00.000Z", "Fix the login bug")
+	writeTestSession(t, web, "sess-b.jsonl", "2026-02-25T06:00:00.000Z", "Style the LOGIN page")
+	// A summary line has no timestamp and does not make sess-c start first.
+	content := `{"type":"summary","summary":"Login script","leafUuid":"r1"}
+{"type":"user","uuid":"u1","timestamp":"2026-02-24T11:00:00Z","sessionId":"sess-c","message":{"role":"user","content":"Run it"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-24T11:00:01Z","sessionId":"sess-c","message":{"role":"assistant","content":[{"type":"text","text":"Running.\nlogin check next"},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"./login.sh"}}]}}
+{"type":"user","uuid":"r1","timestamp":"2026-02-24T11:00:02Z","sessionId":"sess-c","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"login ok"}]}}
+`
+	path := filepath.Join(api, "sess-c.jsonl")
+	os.WriteFile(path, []byte(content), 0644)
+	ts := time.Date(2026, 2, 24, 11, 0, 0, 0, time.UTC)
+	os.Chtimes(path, ts, ts)
+
+	re := regexp.MustCompile(`(?i)login`)
+	matches, err := Search(dir, re, SearchOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The web project was active last, and sess-a started before sess-c.
+	want := []struct{ session, uuid, source, line string }{
+		{"sess-b", "u1", MatchText, "Style the LOGIN page"},
+		{"sess-a", "u1", MatchText, "Fix the login bug"},
+		{"sess-c", "a1", MatchText, "login check next"},
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("matches = %+v, want %d", matches, len(want))
+	}
+	for i, w := range want {
+		m := matches[i]
+		if m.SessionID != w.session || m.UUID != w.uuid || m.Source != w.source || m.Line != w.line {
+			t.Errorf("matches[%d] = %+v, want %+v", i, m, w)
+		}
+	}
+	if got := matches[1].Spans; len(got) != 1 || got[0] != [2]int{8, 13} {
+		t.Errorf("Spans = %v, want [[8 13]]", got)
+	}
+
+	matches, _ = Search(dir, re, SearchOptions{Project: "-work-api", Tools: true})
+	var sources []string
+	for _, m := range matches {
+		sources = append(sources, m.Source)
+	}
+	if len(matches) != 4 || sources[2] != MatchToolInput || sources[3] != MatchToolResult {
+		t.Errorf("with tools: sources = %v, want text, text, tool_input, tool_result", sources)
+	}
+
+	if matches, _ := Search(dir, re, SearchOptions{Limit: 2}); len(matches) != 2 {
+		t.Errorf("with limit: %d matches, want 2", len(matches))
+	}
+}
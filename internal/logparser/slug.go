@@ -0,0 +1,189 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// Sources of a resolved project path, from most to least reliable.
+const (
+	PathSourceCWD        = "cwd"        // Recorded working directory in the session logs
+	PathSourceFilesystem = "filesystem" // Existing directory whose encoding matches the slug
+	PathSourceSlug       = "slug"       // Naive DecodeSlug fallback
+)
+
+// ProjectPath is the workspace path a project slug was created from.
+type ProjectPath struct {
+	Path string
+	// Ambiguous is set when more than one path encodes to the slug, or when
+	// the path had to be guessed from the slug alone.
+	Ambiguous bool
+	Source    string
+}
+
+// cwdScanLines bounds how many lines of each session file are read while
+// looking for a recorded working directory.
+const cwdScanLines = 50
+
+// unresolvedTTL is how long a path guessed from the slug alone is cached
+// before the filesystem is probed again.
+const unresolvedTTL = 5 * time.Minute
+
+var pathCache sync.Map // logDir + "\x00" + slug -> cachedPath
+
+// cachedPath is a resolved project path. Guesses from the slug alone are
+// retried when the project directory changes, e.g. because a session was
+// added, or after unresolvedTTL, in case the workspace was created since.
+type cachedPath struct {
+	ProjectPath
+	dirModTime time.Time // Of the project directory, for guesses
+	expires    time.Time // For guesses
+}
+
+// EncodePath converts a workspace path to the project slug Claude Code uses
+// for its log directory: every character other than an ASCII letter or digit
+// becomes `-`.
+func EncodePath(path string) string {
+	b := []byte(path)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			b[i] = '-'
+		}
+	}
+	return string(b)
+}
+
+// ResolveProjectPath recovers the workspace path of a project. It prefers
+// the `cwd` recorded in the project's session logs, then probes the local
+// filesystem for directories whose encoding matches the slug, and finally
+// falls back to DecodeSlug. Results backed by evidence are cached per
+// project; guesses until the project directory changes or unresolvedTTL
+// passes.
+func ResolveProjectPath(logDir, slug string) ProjectPath {
+	key := logDir + "\x00" + slug
+	projDir := filepath.Join(logDir, slug)
+	if v, ok := pathCache.Load(key); ok {
+		c := v.(cachedPath)
+		if c.Source != PathSourceSlug {
+			return c.ProjectPath
+		}
+		if time.Now().Before(c.expires) && dirModTime(projDir).Equal(c.dirModTime) {
+			return c.ProjectPath
+		}
+	}
+
+	c := cachedPath{dirModTime: dirModTime(projDir), expires: time.Now().Add(unresolvedTTL)}
+	c.ProjectPath = resolveProjectPath(logDir, slug)
+	pathCache.Store(key, c)
+	return c.ProjectPath
+}
+
+// dirModTime returns the modification time of dir, or zero if it cannot
+// be read.
+func dirModTime(dir string) time.Time {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func resolveProjectPath(logDir, slug string) ProjectPath {
+	if paths := pathsFromCWD(filepath.Join(logDir, slug), slug); len(paths) > 0 {
+		return ProjectPath{Path: paths[0], Ambiguous: len(paths) > 1, Source: PathSourceCWD}
+	}
+	if paths := probePaths(slug); len(paths) > 0 {
+		return ProjectPath{Path: paths[0], Ambiguous: len(paths) > 1, Source: PathSourceFilesystem}
+	}
+	// Without evidence, any hyphen after the leading one may have been a
+	// `/`, `.`, `-` or other character in the original path.
+	rest := strings.TrimPrefix(slug, "-")
+	return ProjectPath{Path: DecodeSlug(slug), Ambiguous: strings.Contains(rest, "-"), Source: PathSourceSlug}
+}
+
+// pathsFromCWD returns the distinct workspace paths that encode to slug,
+// derived from the cwd fields of the project's session logs. A cwd inside a
+// subdirectory of the workspace is walked up until its encoding matches.
+func pathsFromCWD(projDir, slug string) []string {
+	files, _ := filepath.Glob(filepath.Join(projDir, "*.jsonl"))
+	seen := make(map[string]bool)
+	var paths []string
+	for _, f := range files {
+		cwd := firstCWD(f)
+		for p := cwd; p != "" && p != filepath.Dir(p); p = filepath.Dir(p) {
+			if EncodePath(p) == slug {
+				if !seen[p] {
+					seen[p] = true
+					paths = append(paths, p)
+				}
+				break
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// firstCWD returns the first cwd recorded near the top of a session file.
+func firstCWD(path string) string {
+	r, err := claudelog.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer r.Close()
+	for e, err := range r.All() {
+		if line, _ := r.Pos(); line > cwdScanLines {
+			break
+		}
+		if err == nil && e.CWD != "" {
+			return e.CWD
+		}
+	}
+	return ""
+}
+
+// maxProbeResults stops filesystem probing once ambiguity is established.
+const maxProbeResults = 2
+
+// probePaths walks the filesystem from the root looking for existing
+// directories whose encoded path equals slug.
+func probePaths(slug string) []string {
+	if !strings.HasPrefix(slug, "-") {
+		return nil
+	}
+	var found []string
+	probe(string(filepath.Separator), slug[1:], &found)
+	sort.Strings(found)
+	return found
+}
+
+func probe(dir, rest string, found *[]string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if len(*found) >= maxProbeResults {
+			return
+		}
+		enc := EncodePath(e.Name())
+		path := filepath.Join(dir, e.Name())
+		if rest == enc {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				*found = append(*found, path)
+			}
+			continue
+		}
+		if strings.HasPrefix(rest, enc+"-") {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				probe(path, rest[len(enc)+1:], found)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEncodePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/Users/alice/workspace/api-server", "-Users-alice-workspace-api-server"},
+		{"/home/bob/.config/app_v2", "-home-bob--config-app-v2"},
+	}
+	for _, tt := range tests {
+		if got := EncodePath(tt.path); got != tt.want {
+			t.Errorf("EncodePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestResolveProjectPath_CWD(t *testing.T) {
+	dir := t.TempDir()
+	slug := "-Users-alice-workspace-api-server"
+	projDir := filepath.Join(dir, slug)
+	os.MkdirAll(projDir, 0755)
+
+	// The first session was started from a subdirectory; the walk up to the
+	// workspace root must still resolve the hyphenated name.
+	lines := `{"type":"summary","summary":"x"}
+{"type":"user","uuid":"u1","timestamp":"2026-02-25T09:00:12.000Z","sessionId":"s1","cwd":"/Users/alice/workspace/api-server/cmd","message":{"role":"user","content":"hi"}}
+`
+	os.WriteFile(filepath.Join(projDir, "s1.jsonl"), []byte(lines), 0644)
+
+	pp := ResolveProjectPath(dir, slug)
+	if pp.Path != "/Users/alice/workspace/api-server" {
+		t.Errorf("Path = %q, want %q", pp.Path, "/Users/alice/workspace/api-server")
+	}
+	if pp.Ambiguous || pp.Source != PathSourceCWD {
+		t.Errorf("got %+v, want unambiguous cwd source", pp)
+	}
+
+	// A second session from a different path with the same encoding makes
+	// the project ambiguous, but the cached result is returned until then.
+	os.WriteFile(filepath.Join(projDir, "s2.jsonl"), []byte(`{"type":"user","cwd":"/Users/alice/workspace/api/server"}`+"\n"), 0644)
+	if cached := ResolveProjectPath(dir, slug); cached != pp {
+		t.Errorf("cached result = %+v, want %+v", cached, pp)
+	}
+	if fresh := resolveProjectPath(dir, slug); !fresh.Ambiguous {
+		t.Errorf("fresh result = %+v, want ambiguous", fresh)
+	}
+}
+
+func TestResolveProjectPath_Filesystem(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "api-server"), 0755)
+
+	slug := EncodePath(filepath.Join(root, "api-server"))
+	pp := resolveProjectPath(t.TempDir(), slug)
+	if pp.Path != filepath.Join(root, "api-server") {
+		t.Errorf("Path = %q, want %q", pp.Path, filepath.Join(root, "api-server"))
+	}
+	if pp.Ambiguous || pp.Source != PathSourceFilesystem {
+		t.Errorf("got %+v, want unambiguous filesystem source", pp)
+	}
+
+	os.MkdirAll(filepath.Join(root, "api", "server"), 0755)
+	if pp := resolveProjectPath(t.TempDir(), slug); !pp.Ambiguous {
+		t.Errorf("got %+v, want ambiguous when both api-server and api/server exist", pp)
+	}
+}
+
+func TestResolveProjectPath_Fallback(t *testing.T) {
+	pp := ResolveProjectPath(t.TempDir(), "-nonexistent-root-proj")
+	if pp.Path != "/nonexistent/root/proj" || pp.Source != PathSourceSlug || !pp.Ambiguous {
+		t.Errorf("got %+v, want ambiguous slug fallback", pp)
+	}
+}
+
+func TestResolveProjectPath_FallbackCached(t *testing.T) {
+	dir := t.TempDir()
+	slug := "-nonexistent-root-proj"
+	projDir := filepath.Join(dir, slug)
+	os.MkdirAll(projDir, 0755)
+	os.WriteFile(filepath.Join(projDir, "s1.jsonl"), []byte(`{"type":"summary","summary":"x"}`+"\n"), 0644)
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(projDir, past, past)
+
+	if pp := ResolveProjectPath(dir, slug); pp.Source != PathSourceSlug {
+		t.Fatalf("got %+v, want slug fallback", pp)
+	}
+
+	// Rewriting a session leaves the directory alone, so the guess is kept.
+	cwdLine := `{"type":"user","cwd":"/nonexistent/root/proj"}` + "\n"
+	os.WriteFile(filepath.Join(projDir, "s1.jsonl"), []byte(cwdLine), 0644)
+	os.Chtimes(projDir, past, past)
+	if pp := ResolveProjectPath(dir, slug); pp.Source != PathSourceSlug {
+		t.Errorf("got %+v, want the cached guess", pp)
+	}
+
+	// A new session changes the directory and the path is resolved again.
+	os.WriteFile(filepath.Join(projDir, "s2.jsonl"), []byte(cwdLine), 0644)
+	os.Chtimes(projDir, time.Now(), time.Now())
+	if pp := ResolveProjectPath(dir, slug); pp.Source != PathSourceCWD || pp.Path != "/nonexistent/root/proj" {
+		t.Errorf("got %+v, want the cwd once a session was added", pp)
+	}
+}
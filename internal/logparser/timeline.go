@@ -0,0 +1,125 @@
+package logparser
+
+import (
+	"time"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// DefaultIdleThreshold is the gap between entries above which the time is
+// counted as idle rather than active.
+const DefaultIdleThreshold = 5 * time.Minute
+
+// Kinds of timeline segments.
+const (
+	SegmentUser       = "user"       // User reading and typing before a message
+	SegmentGeneration = "generation" // Assistant producing a response
+	SegmentTool       = "tool"       // Tool execution, from tool_use to tool_result
+	SegmentIdle       = "idle"       // Gap above the idle threshold
+)
+
+// TimelineSegment is a span of a session attributed to one activity.
+// Consecutive spans of the same kind are merged.
+type TimelineSegment struct {
+	Kind     string        `json:"kind"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Tools    []string      `json:"tools,omitempty"` // Tools run in a tool segment
+	UUID     string        `json:"uuid"`            // Entry that ends the first span
+}
+
+// Timeline summarizes where a session spent its time.
+type Timeline struct {
+	Start, End time.Time
+	Duration   time.Duration // Wall clock from the first to the last entry
+	Active     time.Duration // Duration minus idle gaps
+	Idle       time.Duration
+	IdleGaps   int
+
+	User, Generation, Tool time.Duration
+	Segments               []TimelineSegment
+}
+
+// MultiDay reports whether the session spans more than one calendar day in
+// loc.
+func (t Timeline) MultiDay(loc *time.Location) bool {
+	y1, m1, d1 := t.Start.In(loc).Date()
+	y2, m2, d2 := t.End.In(loc).Date()
+	return y1 != y2 || m1 != m2 || d1 != d2
+}
+
+// BuildTimeline attributes each gap between consecutive entries to the
+// activity that the later entry completes: a tool result ends tool
+// execution, an assistant message ends generation, and a user message ends
+// the user's turn. Gaps longer than idleThreshold count as idle. Entries
+// without a timestamp, such as summaries, are skipped.
+func BuildTimeline(conv *Conversation, idleThreshold time.Duration) Timeline {
+	var tl Timeline
+	tl.Start, tl.End = conv.TimeRange()
+	if tl.Start.IsZero() {
+		return tl
+	}
+	if idleThreshold <= 0 {
+		idleThreshold = DefaultIdleThreshold
+	}
+	tl.Duration = tl.End.Sub(tl.Start)
+
+	var prev time.Time
+	for _, e := range conv.Entries {
+		if e.Timestamp.IsZero() {
+			continue
+		}
+		start := prev
+		prev = e.Timestamp
+		gap := e.Timestamp.Sub(start)
+		if start.IsZero() || gap <= 0 {
+			continue
+		}
+
+		kind := segmentKind(e)
+		if gap > idleThreshold {
+			kind = SegmentIdle
+			tl.IdleGaps++
+		}
+		switch kind {
+		case SegmentIdle:
+			tl.Idle += gap
+		case SegmentUser:
+			tl.User += gap
+		case SegmentGeneration:
+			tl.Generation += gap
+		case SegmentTool:
+			tl.Tool += gap
+		}
+
+		n := len(tl.Segments)
+		if n == 0 || tl.Segments[n-1].Kind != kind {
+			tl.Segments = append(tl.Segments, TimelineSegment{Kind: kind, Start: start, UUID: e.UUID})
+			n++
+		}
+		seg := &tl.Segments[n-1]
+		seg.Duration += gap
+		if kind == SegmentTool {
+			for _, b := range e.Message.Content.Blocks {
+				if r, ok := b.(*claudelog.ToolResultBlock); ok {
+					seg.Tools = appendUnique(seg.Tools, conv.ToolCalls[r.ToolUseID].Name)
+				}
+			}
+		}
+	}
+	tl.Active = tl.Duration - tl.Idle
+	return tl
+}
+
+// segmentKind classifies the activity that ends with entry e.
+func segmentKind(e LogEntry) string {
+	if e.Type == "assistant" {
+		return SegmentGeneration
+	}
+	for _, b := range e.Message.Content.Blocks {
+		if _, ok := b.(*claudelog.ToolResultBlock); ok {
+			return SegmentTool
+		}
+	}
+	return SegmentUser
+}
@@ -0,0 +1,84 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T23:50:00Z","sessionId":"sess-1","message":{"role":"user","content":"Go"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T23:50:20Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{}}]}}
+{"type":"user","uuid":"r1","timestamp":"2026-02-25T23:51:20Z","sessionId":"sess-1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}
+{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T23:51:30Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]}}
+{"type":"user","uuid":"u2","timestamp":"2026-02-26T00:21:30Z","sessionId":"sess-1","message":{"role":"user","content":"Next"}}
+{"type":"assistant","uuid":"a3","timestamp":"2026-02-26T00:21:35Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"text","text":"Ok"}]}}
+{"type":"user","uuid":"u3","timestamp":"2026-02-26T00:22:35Z","sessionId":"sess-1","message":{"role":"user","content":"Thanks"}}
+`
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tl := BuildTimeline(conv, 0)
+	checks := []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"Duration", tl.Duration, 32*time.Minute + 35*time.Second},
+		{"Idle", tl.Idle, 30 * time.Minute},
+		{"Active", tl.Active, 2*time.Minute + 35*time.Second},
+		{"Generation", tl.Generation, 35 * time.Second},
+		{"Tool", tl.Tool, time.Minute},
+		{"User", tl.User, time.Minute},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if tl.IdleGaps != 1 || !tl.MultiDay(time.UTC) {
+		t.Errorf("IdleGaps = %d, MultiDay = %v; want 1 and true", tl.IdleGaps, tl.MultiDay(time.UTC))
+	}
+	if len(tl.Segments) != 6 || tl.Segments[1].Kind != SegmentTool || tl.Segments[1].Tools[0] != "Bash" {
+		t.Errorf("Segments = %+v", tl.Segments)
+	}
+
+	// A higher threshold turns the half-hour gap into user time.
+	if tl := BuildTimeline(conv, time.Hour); tl.Idle != 0 || tl.User != 31*time.Minute {
+		t.Errorf("with 1h threshold: Idle = %v, User = %v", tl.Idle, tl.User)
+	}
+}
+
+func TestBuildTimelineSkipsUntimedEntries(t *testing.T) {
+	content := `{"type":"summary","summary":"Greeting","leafUuid":"a1"}
+{"type":"user","uuid":"u1","timestamp":"2026-02-25T10:00:00Z","sessionId":"sess-1","message":{"role":"user","content":"Hi"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T10:00:10Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"text","text":"Hello"}]}}
+{"type":"summary","summary":"Greeting","leafUuid":"a1"}
+`
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tl := BuildTimeline(conv, 0)
+	want := time.Date(2026, 2, 25, 10, 0, 0, 0, time.UTC)
+	if !tl.Start.Equal(want) || !tl.End.Equal(want.Add(10*time.Second)) {
+		t.Errorf("Start, End = %v, %v; want %v and 10s later", tl.Start, tl.End, want)
+	}
+	if tl.Duration != 10*time.Second || tl.Generation != 10*time.Second || tl.IdleGaps != 0 {
+		t.Errorf("Duration = %v, Generation = %v, IdleGaps = %d; want 10s, 10s, 0", tl.Duration, tl.Generation, tl.IdleGaps)
+	}
+	if len(tl.Segments) != 1 || !tl.Segments[0].Start.Equal(want) {
+		t.Errorf("Segments = %+v, want one starting at %v", tl.Segments, want)
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// handleAPIProjects serves the project list as JSON, accepting the same
+// query parameters as the index page.
+func (s *Server) handleAPIProjects(w http.ResponseWriter, r *http.Request) {
+	v := s.newValidator()
+	v.sessions(s.LogDir, "", s.Index)
+	if notModified(w, r, v) {
+		return
+	}
+	projects, err := s.queryProjects(r)
+	if err != nil {
+		writeAPIListError(w, "failed to list projects", err)
+		return
+	}
+	v.setHeaders(w)
+	writeJSON(w, http.StatusOK, projects)
+}
+
+// handleAPISessions serves a project's session list as JSON, accepting the
+// same query parameters as the project page.
+func (s *Server) handleAPISessions(w http.ResponseWriter, r *http.Request) {
+	v := s.newValidator()
+	v.sessions(s.LogDir, r.PathValue("slug"), s.Index)
+	v.time(s.Store.Modified()) // Stars and tags
+	if notModified(w, r, v) {
+		return
+	}
+	sessions, err := s.querySessions(r, r.PathValue("slug"))
+	if err != nil {
+		writeAPIListError(w, "failed to list sessions", err)
+		return
+	}
+	v.setHeaders(w)
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// handleAPIEntries serves the raw log entries of a session as a JSON array
+// in the pkg/claudelog format. Lines that are not valid entries are
+// skipped; ?type=user,assistant keeps only entries of those types.
+func (s *Server) handleAPIEntries(w http.ResponseWriter, r *http.Request) {
+	f, err := claudelog.Walker{Root: s.LogDir}.Session(r.PathValue("slug"), r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	v := s.newValidator()
+	v.file(f)
+	if notModified(w, r, v) {
+		return
+	}
+	lr, err := f.Open()
+	if err != nil {
+		slog.Error("failed to open session", "error", err, "file", f.Path)
+		writeJSONError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	defer lr.Close()
+	var types []string
+	if t := r.URL.Query().Get("type"); t != "" {
+		types = strings.Split(t, ",")
+	}
+
+	// Stream the array, since sessions can be large.
+	v.setHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	sep := "["
+	for e, err := range lr.All() {
+		if err != nil {
+			slog.Warn("skipping log line", "error", err, "file", f.Path)
+			continue
+		}
+		if types != nil && !slices.Contains(types, e.Type) {
+			continue
+		}
+		io.WriteString(w, sep)
+		sep = ","
+		if err := enc.Encode(e); err != nil {
+			slog.Error("failed to encode entry", "error", err, "file", f.Path)
+			return
+		}
+	}
+	if sep == "[" {
+		io.WriteString(w, "[")
+	}
+	io.WriteString(w, "]\n")
+}
+
+func writeAPIListError(w http.ResponseWriter, msg string, err error) {
+	var bad badRequest
+	if errors.As(err, &bad) {
+		writeJSONError(w, http.StatusBadRequest, bad.Error())
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		w.WriteHeader(statusClientClosedRequest)
+		return
+	}
+	slog.Error(msg, "error", err)
+	writeJSONError(w, http.StatusInternalServerError, "internal server error")
+}
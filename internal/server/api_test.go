@@ -0,0 +1,126 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+func TestAPIProjects(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+
+	req := httptest.NewRequest("GET", "/api/projects?sort=tokens&per_page=10", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var page logparser.Page[logparser.Project]
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 {
+		t.Fatalf("page = %+v, want one project", page)
+	}
+	if page.Items[0].Tokens != 150 {
+		t.Errorf("Tokens = %d, want 150", page.Items[0].Tokens)
+	}
+}
+
+func TestAPISessions(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+
+	req := httptest.NewRequest("GET", "/api/projects/-Users-foo-workspace-proj/sessions?model=opus", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var page logparser.Page[sessionItem]
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 || page.Items[0].FirstMessage != "Hello from test" {
+		t.Errorf("page = %+v, want the single test session", page)
+	}
+}
+
+func TestAPIEntries(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+
+	req := httptest.NewRequest("GET", "/api/sessions/-Users-foo-workspace-proj/sess-1/entries?type=assistant", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var entries []claudelog.Entry
+	if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].UUID != "a1" || entries[0].Message.Usage.InputTokens != 100 {
+		t.Errorf("entries = %+v, want the assistant entry", entries)
+	}
+
+	for _, path := range []string{
+		"/api/sessions/-Users-foo-workspace-proj/nope/entries",
+		"/api/sessions/..%2f..%2fetc/passwd/entries",
+	} {
+		w = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestListParamsValidation(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+
+	for _, path := range []string{
+		"/?sort=bogus",
+		"/?since=yesterday",
+		"/projects/-Users-foo-workspace-proj?page=-1",
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s status = %d, want %d", path, w.Code, http.StatusBadRequest)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/projects?order=sideways", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("API status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestPager(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+	// Add a second project so that per_page=1 yields two pages.
+	setupProject(t, dir, "-Users-foo-workspace-other", "Other project")
+
+	req := httptest.NewRequest("GET", "/?per_page=1", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	body := w.Body.String()
+	if !containsString(body, "Page 1 of 2") {
+		t.Error("index should render the pager")
+	}
+	if !containsString(body, "page=2") {
+		t.Error("pager should link to the next page")
+	}
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/store"
+)
+
+// authorCookie stores the display name a viewer comments under.
+const authorCookie = "ccs_author"
+
+// recentCommentsLimit is the number of comments shown on the index page feed.
+const recentCommentsLimit = 10
+
+// commentAuthor returns the viewer's display name, or "" if unknown.
+// There is no authentication layer; the name is taken from a cookie set
+// when the viewer first comments.
+func commentAuthor(r *http.Request) string {
+	c, err := r.Cookie(authorCookie)
+	if err != nil {
+		return ""
+	}
+	name, err := url.QueryUnescape(c.Value)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+func setCommentAuthor(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     authorCookie,
+		Value:    url.QueryEscape(name),
+		Path:     "/",
+		MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// thread is the template data for the comments attached to one message.
+type thread struct {
+	UUID     string
+	Author   string
+	Comments []store.Comment
+}
+
+func newThread(byMessage map[string][]store.Comment, uuid, author string) thread {
+	return thread{UUID: uuid, Author: author, Comments: byMessage[uuid]}
+}
+
+// commentsByMessage loads a session's comments grouped by message UUID.
+// Comments on a streamed fragment are moved to the entry it was merged into.
+func (s *Server) commentsByMessage(conv *logparser.Conversation) map[string][]store.Comment {
+	sessionID := conv.SessionID
+	comments, err := s.Store.ListComments(sessionID)
+	if err != nil {
+		slog.Warn("failed to load comments", "error", err, "session", sessionID)
+		return nil
+	}
+	byMessage := make(map[string][]store.Comment)
+	for _, c := range comments {
+		uuid := conv.EntryUUID(c.MessageUUID)
+		byMessage[uuid] = append(byMessage[uuid], c)
+	}
+	return byMessage
+}
+
+func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
+	comments, err := s.Store.ListComments(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if comments == nil {
+		comments = []store.Comment{}
+	}
+	writeJSON(w, http.StatusOK, comments)
+}
+
+type commentRequest struct {
+	MessageUUID string `json:"messageUuid"`
+	Author      string `json:"author"`
+	Body        string `json:"body"`
+}
+
+func (s *Server) handleAddComment(w http.ResponseWriter, r *http.Request) {
+	var req commentRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	author := strings.TrimSpace(req.Author)
+	if author == "" {
+		author = commentAuthor(r)
+	}
+	if author == "" {
+		writeJSONError(w, http.StatusBadRequest, "author is required")
+		return
+	}
+
+	// Comments can only be left on messages that exist.
+	slug, sessionID := r.PathValue("slug"), r.PathValue("id")
+	conv, err := s.Conversations.LoadSession(s.LogDir, slug, sessionID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	if !conv.HasEntry(req.MessageUUID) {
+		writeJSONError(w, http.StatusNotFound, "message not found")
+		return
+	}
+
+	c, err := s.Store.AddComment(store.Comment{
+		Slug:        slug,
+		SessionID:   sessionID,
+		MessageUUID: req.MessageUUID,
+		Author:      author,
+		Body:        req.Body,
+	})
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	setCommentAuthor(w, author)
+	writeJSON(w, http.StatusCreated, c)
+}
+
+func (s *Server) handleUpdateComment(w http.ResponseWriter, r *http.Request) {
+	var req commentRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	c, err := s.Store.UpdateComment(r.PathValue("id"), r.PathValue("commentID"), commentAuthor(r), req.Body)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) handleDeleteComment(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeleteComment(r.PathValue("id"), r.PathValue("commentID"), commentAuthor(r)); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeStoreError replies to a failed store call. Only invalid input is
+// described to the client; failures to read or write the data are logged.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, store.ErrForbidden):
+		writeJSONError(w, http.StatusForbidden, "only the author can change this comment")
+	case errors.Is(err, store.ErrInvalid):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	default:
+		slog.Error("store error", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nhosoya/claude-code-share/internal/store"
+)
+
+func TestCommentsAPI(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+	h := srv.Handler()
+	base := "/api/sessions/-Users-foo-workspace-proj/sess-1/comments"
+
+	req := httptest.NewRequest("POST", base, strings.NewReader(`{"messageUuid":"u1","author":"alice","body":"Great prompt"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var created store.Comment
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 || cookies[0].Name != authorCookie {
+		t.Fatal("POST should set the author cookie")
+	}
+
+	// Editing without the author cookie is rejected.
+	req = httptest.NewRequest("PUT", base+"/"+created.ID, strings.NewReader(`{"body":"edited"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("PUT without cookie status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	req = httptest.NewRequest("PUT", base+"/"+created.ID, strings.NewReader(`{"body":"edited"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("PUT status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	req = httptest.NewRequest("GET", "/sessions/-Users-foo-workspace-proj/sess-1", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if !containsString(w.Body.String(), "edited") {
+		t.Error("session page should contain the comment")
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if !containsString(w.Body.String(), "Recently commented") {
+		t.Error("index page should contain the recent comments feed")
+	}
+
+	req = httptest.NewRequest("DELETE", base+"/"+created.ID, nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("DELETE status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	req = httptest.NewRequest("GET", base, nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("GET after delete = %q, want []", w.Body.String())
+	}
+}
+
+func TestAddCommentRejected(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+	h := srv.Handler()
+
+	tests := []struct {
+		name, path, contentType, body string
+		want                          int
+	}{
+		{"form post", "/api/sessions/-Users-foo-workspace-proj/sess-1/comments", "text/plain", `{"messageUuid":"u1","author":"alice","body":"Hi"}`, http.StatusUnsupportedMediaType},
+		{"no content type", "/api/sessions/-Users-foo-workspace-proj/sess-1/comments", "", `{"messageUuid":"u1","author":"alice","body":"Hi"}`, http.StatusUnsupportedMediaType},
+		{"unknown session", "/api/sessions/-Users-foo-workspace-proj/nope/comments", "application/json", `{"messageUuid":"u1","author":"alice","body":"Hi"}`, http.StatusNotFound},
+		{"unknown message", "/api/sessions/-Users-foo-workspace-proj/sess-1/comments", "application/json", `{"messageUuid":"nope","author":"alice","body":"Hi"}`, http.StatusNotFound},
+		{"empty body", "/api/sessions/-Users-foo-workspace-proj/sess-1/comments", "application/json; charset=utf-8", `{"messageUuid":"u1","author":"alice","body":" "}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, w.Code, tt.want, w.Body.String())
+		}
+	}
+	if comments, _ := srv.Store.ListComments("sess-1"); len(comments) != 0 {
+		t.Errorf("rejected requests stored %d comments", len(comments))
+	}
+}
+
+func TestWriteStoreErrorHidesInternalErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeStoreError(w, errors.New("open /secret/path: permission denied"))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("body = %q, should not describe the error", w.Body.String())
+	}
+}
+
+func TestCommentsOnMergedFragments(t *testing.T) {
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-work-app")
+	os.MkdirAll(projDir, 0755)
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55Z","sessionId":"sess-1","message":{"role":"user","content":"Hi"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:41:58Z","sessionId":"sess-1","requestId":"req_1","message":{"id":"msg_1","role":"assistant","content":[{"type":"text","text":"First part"}]}}
+{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T06:41:59Z","sessionId":"sess-1","requestId":"req_1","message":{"id":"msg_1","role":"assistant","content":[{"type":"text","text":"Second part"}]}}
+`
+	os.WriteFile(filepath.Join(projDir, "sess-1.jsonl"), []byte(content), 0644)
+	st := openTestStore(t)
+	// A comment left on a fragment before fragments were merged.
+	if _, err := st.AddComment(store.Comment{Slug: "-work-app", SessionID: "sess-1", MessageUUID: "a2", Author: "alice", Body: "Nice split"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := New(dir, st)
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-work-app/sess-1", nil))
+	body := w.Body.String()
+	if strings.Count(body, `id="msg-a`) != 1 || !strings.Contains(body, "Second part") {
+		t.Error("fragments should render as a single message")
+	}
+	if !strings.Contains(body, "Nice split") {
+		t.Error("comment on a merged fragment should still be shown")
+	}
+}
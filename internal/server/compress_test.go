@@ -0,0 +1,92 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	dir := setupTestLogDir(t)
+	h := New(dir, openTestStore(t)).Handler()
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	const page = "/sessions/-Users-foo-workspace-proj/sess-1"
+	plain := get(page, "")
+	if plain.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Content-Encoding = %q without Accept-Encoding", plain.Header().Get("Content-Encoding"))
+	}
+
+	w := get(page, "br;q=1.0, gzip;q=0.8")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("status = %d, Content-Encoding = %q; want gzip", w.Code, w.Header().Get("Content-Encoding"))
+	}
+	if !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+		t.Errorf("Vary = %q, want Accept-Encoding", w.Header().Values("Vary"))
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != plain.Body.String() {
+		t.Error("decompressed page differs from the uncompressed one")
+	}
+
+	// Small responses, clients refusing gzip and 304s are left alone.
+	if w := get("/healthz", "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("small response Content-Encoding = %q", w.Header().Get("Content-Encoding"))
+	}
+	if w := get(page, "gzip;q=0, identity"); w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("gzip;q=0 Content-Encoding = %q", w.Header().Get("Content-Encoding"))
+	}
+	req := httptest.NewRequest("GET", page, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", plain.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Header().Get("Content-Encoding") != "" || w.Body.Len() != 0 {
+		t.Errorf("304: status = %d, Content-Encoding = %q, %d bytes", w.Code, w.Header().Get("Content-Encoding"), w.Body.Len())
+	}
+}
+
+func TestCompress_Streaming(t *testing.T) {
+	h := compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "[")
+		http.NewResponseController(w).Flush()
+		io.WriteString(w, "1]")
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if !w.Flushed || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("flushed = %v, Content-Encoding = %q; want a flushed gzip stream", w.Flushed, w.Header().Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != "[1]" {
+		t.Errorf("body = %q, want [1]", body)
+	}
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/store"
+)
+
+// sessionItem is a session summary decorated with its curation metadata,
+// as rendered by the "session-item" template.
+type sessionItem struct {
+	logparser.Session
+	Meta store.SessionMeta `json:"meta"`
+}
+
+// decorate attaches stars and tags to a list of session summaries.
+func (s *Server) decorate(sessions []logparser.Session) []sessionItem {
+	meta, err := s.Store.AllSessionMeta()
+	if err != nil {
+		slog.Warn("failed to load session metadata", "error", err)
+	}
+	items := make([]sessionItem, len(sessions))
+	for i, sess := range sessions {
+		items[i] = sessionItem{Session: sess, Meta: meta[sess.ID]}
+	}
+	return items
+}
+
+// loadRefs resolves session references to summaries from the session
+// index, skipping sessions whose log files no longer exist.
+func (s *Server) loadRefs(refs []store.SessionRef) []logparser.Session {
+	defer s.saveIndex()
+	var sessions []logparser.Session
+	for _, ref := range refs {
+		sess, err := s.Index.LoadSessionSummary(s.LogDir, ref.Slug, ref.SessionID)
+		if err != nil {
+			slog.Warn("skipping missing session", "error", err, "slug", ref.Slug, "session", ref.SessionID)
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions
+}
+
+func (s *Server) handleCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := s.Store.Collections()
+	if err != nil {
+		slog.Error("failed to load collections", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	tags, err := s.Store.Tags()
+	if err != nil {
+		slog.Warn("failed to load tags", "error", err)
+	}
+
+	meta, err := s.Store.AllSessionMeta()
+	if err != nil {
+		slog.Warn("failed to load session metadata", "error", err)
+	}
+	var starred []store.SessionRef
+	for _, m := range meta {
+		if m.Starred {
+			starred = append(starred, m.SessionRef)
+		}
+	}
+
+	s.render(w, r, "collections.html", struct {
+		Collections []store.Collection
+		Tags        []store.TagCount
+		Starred     []sessionItem
+	}{
+		Collections: collections,
+		Tags:        tags,
+		Starred:     s.decorate(s.loadRefs(starred)),
+	})
+}
+
+func (s *Server) handleCollection(w http.ResponseWriter, r *http.Request) {
+	c, err := s.Store.Collection(r.PathValue("collection"))
+	if errors.Is(err, store.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to load collection", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	s.render(w, r, "collection.html", struct {
+		Collection store.Collection
+		Sessions   []sessionItem
+	}{
+		Collection: c,
+		Sessions:   s.decorate(s.loadRefs(c.Sessions)),
+	})
+}
+
+func (s *Server) handleTag(w http.ResponseWriter, r *http.Request) {
+	tag := store.NormalizeTag(r.PathValue("tag"))
+	metas, err := s.Store.SessionsWithTag(tag)
+	if err != nil {
+		slog.Error("failed to load tagged sessions", "error", err, "tag", tag)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	refs := make([]store.SessionRef, len(metas))
+	for i, m := range metas {
+		refs[i] = m.SessionRef
+	}
+
+	s.render(w, r, "tag.html", struct {
+		Tag      string
+		Sessions []sessionItem
+	}{
+		Tag:      tag,
+		Sessions: s.decorate(s.loadRefs(refs)),
+	})
+}
+
+func sessionRef(r *http.Request) store.SessionRef {
+	return store.SessionRef{Slug: r.PathValue("slug"), SessionID: r.PathValue("id")}
+}
+
+func (s *Server) handleSetStar(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Starred bool `json:"starred"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if err := s.Store.SetStarred(sessionRef(r), req.Starred); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
+
+func (s *Server) handleSetTags(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	tags, err := s.Store.SetTags(sessionRef(r), req.Tags)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"tags": tags})
+}
+
+func (s *Server) handleListCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := s.Store.Collections()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if collections == nil {
+		collections = []store.Collection{}
+	}
+	writeJSON(w, http.StatusOK, collections)
+}
+
+func (s *Server) handleCreateCollection(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	c, err := s.Store.CreateCollection(req.Name, req.Description)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, c)
+}
+
+func (s *Server) handleUpdateCollection(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Description string `json:"description"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	c, err := s.Store.UpdateCollection(r.PathValue("collection"), req.Description)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) handleDeleteCollection(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeleteCollection(r.PathValue("collection")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleAddToCollection(w http.ResponseWriter, r *http.Request) {
+	var ref store.SessionRef
+	if !decodeJSON(w, r, &ref) {
+		return
+	}
+	if err := s.Store.AddToCollection(r.PathValue("collection"), ref); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRemoveFromCollection(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.RemoveFromCollection(r.PathValue("collection"), sessionRef(r)); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCurationPages(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+	h := srv.Handler()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("PUT", "/api/sessions/-Users-foo-workspace-proj/sess-1/star", `{"starred":true}`); w.Code != http.StatusOK {
+		t.Fatalf("star status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("PUT", "/api/sessions/-Users-foo-workspace-proj/sess-1/tags", `{"tags":["Debugging"]}`); w.Code != http.StatusOK {
+		t.Fatalf("tags status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/collections", `{"name":"Greatest hits","description":"Good stuff"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create collection status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/collections/greatest-hits/sessions", `{"slug":"-Users-foo-workspace-proj","sessionId":"sess-1"}`); w.Code != http.StatusNoContent {
+		t.Fatalf("add to collection status = %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/collections", "Greatest hits"},
+		{"/collections", "Hello from test"}, // starred section
+		{"/collections/greatest-hits", "Hello from test"},
+		{"/tags/debugging", "Hello from test"},
+		{"/projects/-Users-foo-workspace-proj?tag=debugging", "Hello from test"},
+		{"/sessions/-Users-foo-workspace-proj/sess-1", "Starred"},
+	}
+	for _, tt := range tests {
+		w := do("GET", tt.path, "")
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s status = %d, want %d", tt.path, w.Code, http.StatusOK)
+		}
+		if !containsString(w.Body.String(), tt.want) {
+			t.Errorf("GET %s should contain %q", tt.path, tt.want)
+		}
+	}
+
+	w := do("GET", "/projects/-Users-foo-workspace-proj?tag=other", "")
+	if containsString(w.Body.String(), "Hello from test") {
+		t.Error("tag filter should hide sessions without the tag")
+	}
+	if w := do("GET", "/collections/missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("missing collection status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := do("DELETE", "/api/collections/greatest-hits/sessions/-Users-foo-workspace-proj/sess-1", ""); w.Code != http.StatusNoContent {
+		t.Errorf("remove from collection status = %d", w.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+)
+
+// maxDiagnosticErrors bounds the parse errors listed on the diagnostics
+// page.
+const maxDiagnosticErrors = 200
+
+// diagnosticError is a parse error together with the session it is in.
+type diagnosticError struct {
+	logparser.Diagnostic
+	Slug, SessionID string
+}
+
+// handleDiagnostics reports the parse health of every session, or of one
+// project with ?project=slug. Diagnostics are kept in the session index, so
+// only sessions that changed since the last visit are parsed.
+func (s *Server) handleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	slug := r.URL.Query().Get("project")
+	if strings.ContainsAny(slug, `/\`) || slug == ".." {
+		http.Error(w, "invalid project", http.StatusBadRequest)
+		return
+	}
+	path := ""
+	if slug != "" {
+		path = logparser.ResolveProjectPath(s.LogDir, slug).Path
+	}
+	report, err := s.Index.DiagnoseArchive(r.Context(), s.LogDir, slug)
+	if err != nil {
+		writeListError(w, "failed to diagnose sessions", err)
+		return
+	}
+	s.saveIndex()
+
+	errs := make([]diagnosticError, 0, min(len(report.Errors), maxDiagnosticErrors))
+	for _, d := range report.Errors[:min(len(report.Errors), maxDiagnosticErrors)] {
+		e := diagnosticError{Diagnostic: d}
+		if rel, err := filepath.Rel(s.LogDir, d.File); err == nil {
+			e.Slug, e.SessionID = filepath.Split(rel)
+			e.Slug = strings.TrimSuffix(e.Slug, string(filepath.Separator))
+			e.SessionID = strings.TrimSuffix(e.SessionID, ".jsonl")
+		}
+		errs = append(errs, e)
+	}
+
+	s.render(w, r, "diagnostics.html", struct {
+		Report  *logparser.DiagnosticReport
+		Errors  []diagnosticError
+		Omitted int
+		Slug    string
+		Path    string
+	}{
+		Report:  report,
+		Errors:  errs,
+		Omitted: len(report.Errors) - len(errs),
+		Slug:    slug,
+		Path:    path,
+	})
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandleDiagnostics(t *testing.T) {
+	dir := setupTestLogDir(t)
+	f, err := os.OpenFile(filepath.Join(dir, "-Users-foo-workspace-proj", "sess-1.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{not json\n{\"type\":\"attachment\",\"sessionId\":\"sess-1\"}\n")
+	f.Close()
+	srv := New(dir, openTestStore(t))
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/diagnostics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"1 with errors",
+		`href="/sessions/-Users-foo-workspace-proj/sess-1"`,
+		"malformed_line",
+		"line 3, byte",
+		"<code>attachment</code>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("diagnostics page missing %q", want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/diagnostics?project=..", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status for project=.. = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+// validator identifies the version of a response for conditional requests.
+// Everything the response is built from is hashed into its ETag. While it
+// is built from modification times alone, the latest of them also serves
+// as Last-Modified. Methods on a nil validator do nothing.
+type validator struct {
+	h       hash.Hash64
+	modTime time.Time
+	untimed bool // Depends on something without a modification time
+}
+
+// newValidator returns a validator for a response that depends on the
+// session logs and the running binary, whose templates and parsing only
+// change on restart.
+func (s *Server) newValidator() *validator {
+	v := &validator{h: fnv.New64a()}
+	v.time(s.started)
+	return v
+}
+
+// pageValidator returns a validator for an HTML page, which also depends
+// on the store and on the viewer's time zone and name. A request choosing a
+// time zone with ?tz= gets nil, so the page is rendered and the choice
+// remembered.
+func (s *Server) pageValidator(w http.ResponseWriter, r *http.Request) *validator {
+	w.Header().Add("Vary", "Cookie")
+	if r.URL.Query().Has("tz") {
+		return nil
+	}
+	v := s.newValidator()
+	v.time(s.Store.Modified())
+	v.add(s.location(r).String(), commentAuthor(r))
+	return v
+}
+
+// add hashes strings the response depends on. Their changes cannot be
+// dated, so the response gets no Last-Modified.
+func (v *validator) add(parts ...string) {
+	if v == nil {
+		return
+	}
+	v.untimed = true
+	v.hash(parts...)
+}
+
+func (v *validator) hash(parts ...string) {
+	for _, p := range parts {
+		v.h.Write([]byte(p))
+		v.h.Write([]byte{0})
+	}
+}
+
+// time hashes a modification time, keeping the latest as Last-Modified.
+func (v *validator) time(t time.Time) {
+	if v == nil {
+		return
+	}
+	v.h.Write(binary.LittleEndian.AppendUint64(nil, uint64(t.UnixNano())))
+	if t.After(v.modTime) {
+		v.modTime = t
+	}
+}
+
+// file adds a session log by its size and modification time.
+func (v *validator) file(f claudelog.SessionFile) {
+	if v == nil {
+		return
+	}
+	v.hash(f.Path, strconv.FormatInt(f.Size, 10))
+	v.time(f.ModTime)
+}
+
+// session adds the log of one session. A missing log is left out, which
+// still changes the tag.
+func (v *validator) session(logDir, slug, id string) {
+	if v == nil {
+		return
+	}
+	f, err := claudelog.Walker{Root: logDir}.Session(slug, id)
+	if err != nil {
+		v.add("missing")
+		return
+	}
+	v.file(f)
+}
+
+// sessions adds the logs of a project's sessions, or of every project if
+// slug is empty, and the version of the index summarizing them. Deleted
+// sessions change the tag but not the latest modification time.
+func (v *validator) sessions(logDir, slug string, ix *logparser.SessionIndex) {
+	if v == nil {
+		return
+	}
+	w := claudelog.Walker{Root: logDir}
+	if slug == "" {
+		w.Walk(func(f claudelog.SessionFile) error {
+			v.file(f)
+			return nil
+		})
+	} else if files, err := w.Sessions(slug); err == nil {
+		for _, f := range files {
+			v.file(f)
+		}
+	}
+	v.add(strconv.FormatInt(ix.Version(), 10))
+}
+
+// relative adds the current minute, for pages showing how long ago things
+// happened.
+func (v *validator) relative(now time.Time) {
+	v.time(now.Truncate(time.Minute))
+}
+
+// etag returns the validator's entity tag. It is weak, so it still matches
+// once the response has been compressed.
+func (v *validator) etag() string {
+	return fmt.Sprintf(`W/"%x"`, v.h.Sum64())
+}
+
+// notModified reports whether the request's If-None-Match, or failing
+// that its If-Modified-Since, shows the client already has the version v
+// identifies, and if so replies 304 Not Modified. Only GET and HEAD
+// requests with a non-nil validator are answered this way, and
+// If-Modified-Since only when v has a Last-Modified.
+func notModified(w http.ResponseWriter, r *http.Request, v *validator) bool {
+	if v == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+	if inm := r.Header.Values("If-None-Match"); len(inm) > 0 {
+		if !etagMatch(strings.Join(inm, ","), v.etag()) {
+			return false
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err != nil || v.untimed || v.modTime.Truncate(time.Second).After(since) {
+		return false
+	}
+	v.setHeaders(w)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// setHeaders sets the ETag and, if it has one, the Last-Modified of v on
+// a response. Handlers call it once they know the response will succeed,
+// so errors carry no validator.
+func (v *validator) setHeaders(w http.ResponseWriter) {
+	if v == nil {
+		return
+	}
+	h := w.Header()
+	h.Set("ETag", v.etag())
+	if !v.untimed {
+		h.Set("Last-Modified", v.modTime.UTC().Format(http.TimeFormat))
+	}
+	h.Set("Cache-Control", "no-cache") // Cache, but revalidate every time
+}
+
+// etagMatch reports whether a comma-separated If-None-Match list includes
+// etag, comparing weakly.
+func etagMatch(list, etag string) bool {
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/internal/store"
+)
+
+func TestConditionalGet(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+	h := srv.Handler()
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	const page = "/sessions/-Users-foo-workspace-proj/sess-1"
+	first := get(page)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q; want 200 with a tag", first.Code, etag)
+	}
+	// The page also depends on the viewer, which has no modification time.
+	if lm := first.Header().Get("Last-Modified"); lm != "" {
+		t.Errorf("Last-Modified = %q, want none", lm)
+	}
+
+	if w := get(page, "If-None-Match", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match: status = %d with %d bytes, want 304 and no body", w.Code, w.Body.Len())
+	}
+	if w := get(page, "If-None-Match", `"other", `+etag); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match list: status = %d, want 304", w.Code)
+	}
+	if w := get(page, "If-Modified-Since", time.Now().UTC().Format(http.TimeFormat)); w.Code != http.StatusOK {
+		t.Errorf("If-Modified-Since on a page: status = %d, want 200", w.Code)
+	}
+
+	// The page depends on the viewer's time zone, and ?tz= is always
+	// rendered so the choice is remembered.
+	if w := get(page, "If-None-Match", etag, "Cookie", tzCookie+"=Asia/Tokyo"); w.Code != http.StatusOK {
+		t.Errorf("other time zone: status = %d, want 200", w.Code)
+	}
+	if w := get(page+"?tz=UTC", "If-None-Match", etag); w.Code != http.StatusOK {
+		t.Errorf("?tz=: status = %d, want 200", w.Code)
+	}
+
+	// A comment changes the page.
+	if _, err := srv.Store.AddComment(store.Comment{Slug: "-Users-foo-workspace-proj", SessionID: "sess-1", MessageUUID: "u1", Body: "Nice"}); err != nil {
+		t.Fatal(err)
+	}
+	w := get(page, "If-None-Match", etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("after comment: status = %d, ETag = %q; want 200 with a new tag", w.Code, w.Header().Get("ETag"))
+	}
+	etag = w.Header().Get("ETag")
+
+	// So does the session log growing.
+	path := filepath.Join(dir, "-Users-foo-workspace-proj", "sess-1.jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"type":"user","uuid":"u2","timestamp":"2026-02-25T06:43:00Z","sessionId":"sess-1","message":{"role":"user","content":"Thanks"}}` + "\n")
+	f.Close()
+	if w := get(page, "If-None-Match", etag); w.Code != http.StatusOK {
+		t.Errorf("after append: status = %d, want 200", w.Code)
+	}
+}
+
+func TestConditionalGet_Endpoints(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+	h := srv.Handler()
+
+	for _, path := range []string{
+		"/api/projects",
+		"/api/projects/-Users-foo-workspace-proj/sessions",
+		"/api/sessions/-Users-foo-workspace-proj/sess-1/entries",
+		"/sessions/-Users-foo-workspace-proj/sess-1/patch",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || etag == "" {
+			t.Errorf("%s: status = %d, ETag = %q; want 200 with a tag", path, w.Code, etag)
+			continue
+		}
+
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusNotModified {
+			t.Errorf("%s: status = %d, want 304", path, w.Code)
+		}
+	}
+
+	// A new session changes the lists.
+	req := httptest.NewRequest("GET", "/api/projects", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	setupProject(t, dir, "-Users-foo-workspace-other", "Another")
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("after new project: status = %d, want 200", w.Code)
+	}
+}
+
+func TestConditionalGet_ModifiedSince(t *testing.T) {
+	dir := setupTestLogDir(t)
+	h := New(dir, openTestStore(t)).Handler()
+	get := func(header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/sessions/-Users-foo-workspace-proj/sess-1/entries", nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// The entries depend only on the session log, so they are dated.
+	first := get()
+	lastModified := first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || lastModified == "" {
+		t.Fatalf("status = %d, Last-Modified = %q; want 200 with a date", first.Code, lastModified)
+	}
+	if w := get("If-Modified-Since", lastModified); w.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since: status = %d, want 304", w.Code)
+	}
+	if w := get("If-None-Match", `"other"`, "If-Modified-Since", lastModified); w.Code != http.StatusOK {
+		t.Errorf("stale If-None-Match with If-Modified-Since: status = %d, want 200", w.Code)
+	}
+
+	// Lists also change when a session is deleted, which has no date.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/projects", nil))
+	if lm := w.Header().Get("Last-Modified"); lm != "" {
+		t.Errorf("project list Last-Modified = %q, want none", lm)
+	}
+}
+
+func TestConditionalGet_NotFound(t *testing.T) {
+	h := New(setupTestLogDir(t), openTestStore(t)).Handler()
+	for _, path := range []string{
+		"/sessions/-Users-foo-workspace-proj/nope",
+		"/sessions/-Users-foo-workspace-proj/nope/patch",
+		"/api/sessions/-Users-foo-workspace-proj/nope/entries",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", path, w.Code)
+		}
+		for _, k := range []string{"ETag", "Last-Modified", "Cache-Control"} {
+			if v := w.Header().Get(k); v != "" {
+				t.Errorf("%s: %s = %q, want none", path, k, v)
+			}
+		}
+	}
+}
+
+func TestEtagMatch(t *testing.T) {
+	tests := []struct {
+		list string
+		want bool
+	}{
+		{`W/"abc"`, true},
+		{`"abc"`, true},
+		{`"x", W/"abc"`, true},
+		{`*`, true},
+		{`"abcd"`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatch(tt.list, `W/"abc"`); got != tt.want {
+			t.Errorf("etagMatch(%q) = %v, want %v", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestNotModified_Unsafe(t *testing.T) {
+	v := New(t.TempDir(), openTestStore(t)).newValidator()
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("If-None-Match", "*")
+	if notModified(httptest.NewRecorder(), req, v) {
+		t.Error("POST answered 304")
+	}
+}
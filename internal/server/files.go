@@ -0,0 +1,73 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+)
+
+// handleProjectFiles lists the files touched across a project's sessions.
+// With ?path=, it also lists the sessions that touched that file.
+func (s *Server) handleProjectFiles(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	v := s.pageValidator(w, r)
+	v.sessions(s.LogDir, slug, s.Index)
+	v.relative(time.Now())
+	if notModified(w, r, v) {
+		return
+	}
+	files, err := s.Conversations.ProjectFiles(r.Context(), s.LogDir, slug)
+	if err != nil {
+		slog.Error("failed to list project files", "error", err, "slug", slug)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	var selected *logparser.ProjectFile
+	var sessions []sessionItem
+	if path := r.URL.Query().Get("path"); path != "" {
+		for i := range files {
+			if files[i].Path == path || files[i].Rel == path {
+				selected = &files[i]
+				break
+			}
+		}
+		if selected == nil {
+			http.NotFound(w, r)
+			return
+		}
+		// The summaries come from the index, newest first.
+		all, err := s.Index.ListSessionsContext(r.Context(), s.LogDir, slug)
+		if err != nil {
+			slog.Error("failed to list sessions", "error", err, "slug", slug)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		s.saveIndex()
+		var touched []logparser.Session
+		for _, sess := range all {
+			if slices.Contains(selected.Sessions, sess.ID) {
+				touched = append(touched, sess)
+			}
+		}
+		sessions = s.decorate(touched)
+	}
+
+	v.setHeaders(w)
+	s.render(w, r, "files.html", struct {
+		Slug     string
+		Path     string
+		Files    []logparser.ProjectFile
+		Selected *logparser.ProjectFile
+		Sessions []sessionItem
+	}{
+		Slug:     slug,
+		Path:     logparser.ResolveProjectPath(s.LogDir, slug).Path,
+		Files:    files,
+		Selected: selected,
+		Sessions: sessions,
+	})
+}
@@ -1,11 +1,19 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/store"
 )
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
@@ -13,17 +21,39 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	v := s.pageValidator(w, r)
+	v.sessions(s.LogDir, "", s.Index)
+	v.relative(time.Now())
+	if notModified(w, r, v) {
+		return
+	}
 
-	projects, err := logparser.ListProjects(s.LogDir)
+	// Group every matching project before paging, so that a repository is
+	// never split across pages and its totals cover all its projects.
+	projects, p, err := s.filterProjects(r)
 	if err != nil {
-		slog.Error("failed to list projects", "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		writeListError(w, "failed to list projects", err)
 		return
 	}
+	repos := logparser.Paginate(logparser.GroupProjects(projects), p.Page, p.PerPage)
+
+	recent, err := s.Store.RecentComments(recentCommentsLimit)
+	if err != nil {
+		slog.Warn("failed to load recent comments", "error", err)
+	}
 
-	s.render(w, "index.html", struct {
-		Projects []logparser.Project
-	}{Projects: projects})
+	v.setHeaders(w)
+	s.render(w, r, "index.html", struct {
+		Repos          []logparser.Repository
+		Pager          pager
+		Params         url.Values
+		RecentComments []store.Comment
+	}{
+		Repos:          repos.Items,
+		Pager:          newPager(repos, r.URL),
+		Params:         r.URL.Query(),
+		RecentComments: recent,
+	})
 }
 
 func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
@@ -34,22 +64,55 @@ func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	v := s.pageValidator(w, r)
+	v.sessions(s.LogDir, slug, s.Index)
+	v.relative(time.Now())
+	if notModified(w, r, v) {
+		return
+	}
 
-	sessions, err := logparser.ListSessions(s.LogDir, slug)
+	sessions, err := s.querySessions(r, slug)
 	if err != nil {
-		slog.Error("failed to list sessions", "error", err, "slug", slug)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		writeListError(w, "failed to list sessions", err)
 		return
 	}
 
-	s.render(w, "project.html", struct {
-		Slug     string
-		Path     string
-		Sessions []logparser.Session
+	// Offer every tag used in this project, not just those on the current page.
+	var tags []string
+	if meta, err := s.Store.AllSessionMeta(); err == nil {
+		for _, m := range meta {
+			if m.Slug != slug {
+				continue
+			}
+			for _, t := range m.Tags {
+				if !slices.Contains(tags, t) {
+					tags = append(tags, t)
+				}
+			}
+		}
+	}
+	sort.Strings(tags)
+
+	pp := logparser.ResolveProjectPath(s.LogDir, slug)
+	v.setHeaders(w)
+	s.render(w, r, "project.html", struct {
+		Slug          string
+		Path          string
+		PathAmbiguous bool
+		Sessions      []sessionItem
+		Pager         pager
+		Params        url.Values
+		Tags          []string
+		Tag           string
 	}{
-		Slug:     slug,
-		Path:     logparser.DecodeSlug(slug),
-		Sessions: sessions,
+		Slug:          slug,
+		Path:          pp.Path,
+		PathAmbiguous: pp.Ambiguous,
+		Sessions:      sessions.Items,
+		Pager:         newPager(sessions, r.URL),
+		Params:        r.URL.Query(),
+		Tags:          tags,
+		Tag:           store.NormalizeTag(r.URL.Query().Get("tag")),
 	})
 }
 
@@ -69,22 +132,96 @@ func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	conv, err := logparser.LoadSession(s.LogDir, slug, sessionID)
+	idle := logparser.DefaultIdleThreshold
+	if v := r.URL.Query().Get("idle"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("invalid idle %q: want a positive duration such as 10m", v), http.StatusBadRequest)
+			return
+		}
+		idle = d
+	}
+	v := s.pageValidator(w, r)
+	v.session(s.LogDir, slug, sessionID)
+	if notModified(w, r, v) {
+		return
+	}
+
+	conv, err := s.Conversations.LoadSession(s.LogDir, slug, sessionID)
 	if err != nil {
 		slog.Error("failed to load session", "error", err, "slug", slug, "session", sessionID)
 		http.Error(w, "Not Found", http.StatusNotFound)
 		return
 	}
 
-	s.render(w, "session.html", struct {
+	meta, err := s.Store.SessionMeta(sessionID)
+	if err != nil {
+		slog.Warn("failed to load session metadata", "error", err, "session", sessionID)
+	}
+	collections, err := s.Store.Collections()
+	if err != nil {
+		slog.Warn("failed to load collections", "error", err)
+	}
+
+	timeline := logparser.BuildTimeline(conv, idle)
+	multiDay := timeline.MultiDay(s.location(r))
+	timeLayout := "15:04"
+	if multiDay {
+		timeLayout = "Jan 2 15:04"
+	}
+
+	v.setHeaders(w)
+	s.render(w, r, "session.html", struct {
 		Slug         string
 		Path         string
 		SessionID    string
 		Conversation *logparser.Conversation
+		Timeline     logparser.Timeline
+		Context      logparser.ContextUsage
+		IdleAfter    time.Duration
+		MultiDay     bool
+		TimeLayout   string // Layout of per-message times
+		Files        []logparser.FileActivity
+		FileHistory  []logparser.FileHistory
+		Comments     map[string][]store.Comment
+		Author       string
+		Meta         store.SessionMeta
+		Collections  []store.Collection
 	}{
 		Slug:         slug,
-		Path:         logparser.DecodeSlug(slug),
+		Path:         logparser.ResolveProjectPath(s.LogDir, slug).Path,
 		SessionID:    sessionID,
 		Conversation: conv,
+		Timeline:     timeline,
+		Context:      logparser.BuildContextUsage(conv),
+		IdleAfter:    idle,
+		MultiDay:     multiDay,
+		TimeLayout:   timeLayout,
+		Files:        logparser.SessionFiles(conv),
+		FileHistory:  logparser.LoadFileHistory(s.LogDir, conv),
+		Comments:     s.commentsByMessage(conv),
+		Author:       commentAuthor(r),
+		Meta:         meta,
+		Collections:  collections,
 	})
 }
+
+// statusClientClosedRequest is logged for requests abandoned by the client
+// before the response was ready, following nginx.
+const statusClientClosedRequest = 499
+
+// writeListError reports a list query failure, distinguishing invalid
+// query parameters from internal errors.
+func writeListError(w http.ResponseWriter, msg string, err error) {
+	var bad badRequest
+	if errors.As(err, &bad) {
+		http.Error(w, bad.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		w.WriteHeader(statusClientClosedRequest)
+		return
+	}
+	slog.Error(msg, "error", err)
+	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+}
@@ -1,16 +1,23 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/store"
 )
 
 func TestHandleIndex(t *testing.T) {
 	dir := setupTestLogDir(t)
-	srv := New(dir)
+	srv := New(dir, openTestStore(t))
 
 	req := httptest.NewRequest("GET", "/", nil)
 	w := httptest.NewRecorder()
@@ -28,9 +35,51 @@ func TestHandleIndex(t *testing.T) {
 	}
 }
 
+func TestHandleIndex_RepositoryPages(t *testing.T) {
+	// Two projects in one git repository, with a project outside it whose
+	// activity falls between theirs.
+	root := t.TempDir()
+	repo, plain := filepath.Join(root, "app"), filepath.Join(root, "scratch")
+	os.MkdirAll(filepath.Join(repo, ".git"), 0755)
+	os.MkdirAll(filepath.Join(repo, "cmd"), 0755)
+	os.MkdirAll(plain, 0755)
+
+	dir := t.TempDir()
+	for i, cwd := range []string{repo, plain, filepath.Join(repo, "cmd")} {
+		projDir := filepath.Join(dir, logparser.EncodePath(cwd))
+		os.MkdirAll(projDir, 0755)
+		line := fmt.Sprintf(`{"type":"user","uuid":"u1","timestamp":"2026-02-2%dT10:00:00Z","sessionId":"s","cwd":%q,"message":{"role":"user","content":"Hello"}}`+"\n", 5-i, cwd)
+		os.WriteFile(filepath.Join(projDir, "s.jsonl"), []byte(line), 0644)
+	}
+	srv := New(dir, openTestStore(t))
+
+	// One repository per page: the first holds both of the repository's
+	// projects, even though a newer project lies between them.
+	req := httptest.NewRequest("GET", "/?per_page=1", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	body := w.Body.String()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if !strings.Contains(body, "2 projects") || strings.Contains(body, plain) {
+		t.Errorf("page 1 should hold the repository with both projects and not %s:\n%s", plain, body)
+	}
+	if !strings.Contains(body, "Page 1 of 2 (2 total)") {
+		t.Error("pager should count repositories")
+	}
+
+	req = httptest.NewRequest("GET", "/?per_page=1&page=2", nil)
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if body := w.Body.String(); !strings.Contains(body, plain) || strings.Contains(body, "2 projects") {
+		t.Errorf("page 2 should hold only %s:\n%s", plain, body)
+	}
+}
+
 func TestHandleProject(t *testing.T) {
 	dir := setupTestLogDir(t)
-	srv := New(dir)
+	srv := New(dir, openTestStore(t))
 
 	req := httptest.NewRequest("GET", "/projects/-Users-foo-workspace-proj", nil)
 	w := httptest.NewRecorder()
@@ -45,9 +94,26 @@ func TestHandleProject(t *testing.T) {
 	}
 }
 
+func TestHandleProject_ClientGone(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+
+	for _, path := range []string{"/projects/-Users-foo-workspace-proj", "/api/projects?sort=tokens"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest("GET", path, nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		if w.Code != statusClientClosedRequest {
+			t.Errorf("%s: status = %d, want %d", path, w.Code, statusClientClosedRequest)
+		}
+	}
+}
+
 func TestHandleSession(t *testing.T) {
 	dir := setupTestLogDir(t)
-	srv := New(dir)
+	srv := New(dir, openTestStore(t))
 
 	req := httptest.NewRequest("GET", "/sessions/-Users-foo-workspace-proj/sess-1", nil)
 	w := httptest.NewRecorder()
@@ -67,7 +133,7 @@ func TestHandleSession(t *testing.T) {
 
 func TestHandleNotFound(t *testing.T) {
 	dir := setupTestLogDir(t)
-	srv := New(dir)
+	srv := New(dir, openTestStore(t))
 
 	req := httptest.NewRequest("GET", "/nonexistent", nil)
 	w := httptest.NewRecorder()
@@ -81,14 +147,28 @@ func TestHandleNotFound(t *testing.T) {
 func setupTestLogDir(t *testing.T) string {
 	t.Helper()
 	dir := t.TempDir()
-	projDir := filepath.Join(dir, "-Users-foo-workspace-proj")
+	setupProject(t, dir, "-Users-foo-workspace-proj", "Hello from test")
+	return dir
+}
+
+func setupProject(t *testing.T, dir, slug, firstMsg string) {
+	t.Helper()
+	projDir := filepath.Join(dir, slug)
 	os.MkdirAll(projDir, 0755)
 
-	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55.945Z","sessionId":"sess-1","message":{"role":"user","content":"Hello from test"}}
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55.945Z","sessionId":"sess-1","message":{"role":"user","content":"` + firstMsg + `"}}
 {"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:02.218Z","sessionId":"sess-1","message":{"model":"claude-opus-4-6","role":"assistant","content":[{"type":"text","text":"Hi there!"}],"usage":{"input_tokens":100,"output_tokens":50,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}
 `
 	os.WriteFile(filepath.Join(projDir, "sess-1.jsonl"), []byte(content), 0644)
-	return dir
+}
+
+func openTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	st, err := store.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
 }
 
 func containsString(s, substr string) bool {
@@ -105,3 +185,199 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestHandleProjectFiles(t *testing.T) {
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-work-app")
+	os.MkdirAll(projDir, 0755)
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55.945Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":"Fix the bug"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:02.218Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Edit","input":{"file_path":"/work/app/src/bug.go"}}]}}
+`
+	os.WriteFile(filepath.Join(projDir, "sess-1.jsonl"), []byte(content), 0644)
+	srv := New(dir, openTestStore(t))
+
+	for _, tc := range []struct {
+		url    string
+		status int
+		want   string
+	}{
+		{"/projects/-work-app/files", http.StatusOK, "src/bug.go"},
+		{"/projects/-work-app/files?path=src/bug.go", http.StatusOK, "Fix the bug"},
+		{"/projects/-work-app/files?path=missing.go", http.StatusNotFound, ""},
+		{"/sessions/-work-app/sess-1", http.StatusOK, "Files touched (1)"},
+	} {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", tc.url, nil))
+		if w.Code != tc.status {
+			t.Errorf("GET %s: status = %d, want %d", tc.url, w.Code, tc.status)
+		}
+		if tc.want != "" && !containsString(w.Body.String(), tc.want) {
+			t.Errorf("GET %s: response should contain %q", tc.url, tc.want)
+		}
+	}
+}
+
+func TestHandleSession_FileHistory(t *testing.T) {
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-work-app")
+	os.MkdirAll(projDir, 0755)
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55.945Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":"Add a file"}}
+{"type":"file-history-snapshot","messageId":"u1","snapshot":{"messageId":"u1","trackedFileBackups":{"/work/app/new.go":{"backupFileName":null,"version":1,"backupTime":"2026-02-25T06:42:03Z"}},"timestamp":"2026-02-25T06:41:55.945Z"},"isSnapshotUpdate":true}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:02.218Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Write","input":{"file_path":"/work/app/new.go","content":"package <main>\n"}}]}}
+{"type":"user","uuid":"r1","timestamp":"2026-02-25T06:42:04Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}
+`
+	os.WriteFile(filepath.Join(projDir, "sess-1.jsonl"), []byte(content), 0644)
+	srv := New(dir, openTestStore(t))
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-work-app/sess-1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"File history", "Final (reconstructed)", `<span class="diff-add">+package &lt;main&gt;`} {
+		if !containsString(body, want) {
+			t.Errorf("response should contain %q", want)
+		}
+	}
+}
+
+func TestHandleSessionPatch(t *testing.T) {
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-work-app")
+	os.MkdirAll(projDir, 0755)
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55.945Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":"Add a file"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:02.218Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Write","input":{"file_path":"/work/app/new.go","content":"package main\n"}}]}}
+{"type":"user","uuid":"r1","timestamp":"2026-02-25T06:42:04Z","sessionId":"sess-1","cwd":"/work/app","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}
+`
+	os.WriteFile(filepath.Join(projDir, "sess-1.jsonl"), []byte(content), 0644)
+	srv := New(dir, openTestStore(t))
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-work-app/sess-1/patch", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if want := "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+package main\n"; !containsString(w.Body.String(), want) {
+		t.Errorf("patch = %q, want it to contain %q", w.Body.String(), want)
+	}
+
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-work-app/sess-1/patch?from=9", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("out of range status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-work-app/missing/patch", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("missing session status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleSession_Failures(t *testing.T) {
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-work-app")
+	os.MkdirAll(projDir, 0755)
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55.945Z","sessionId":"sess-1","message":{"role":"user","content":"Run the tests"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:02.218Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test"}}]}}
+{"type":"user","uuid":"r1","timestamp":"2026-02-25T06:42:04Z","sessionId":"sess-1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"Exit code 1\nFAIL","is_error":true}]}}
+{"type":"user","uuid":"u2","timestamp":"2026-02-25T06:42:05Z","sessionId":"sess-1","message":{"role":"user","content":[{"type":"text","text":"[Request interrupted by user]"}]}}
+`
+	os.WriteFile(filepath.Join(projDir, "sess-1.jsonl"), []byte(content), 0644)
+	srv := New(dir, openTestStore(t))
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-work-app/sess-1", nil))
+	body := w.Body.String()
+	for _, want := range []string{"has-failure", `title="Exit code 1">exit_code`, "1 failed tool call", "Interrupted by user"} {
+		if !containsString(body, want) {
+			t.Errorf("session page should contain %q", want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/projects/-work-app?sort=failures", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if !containsString(w.Body.String(), "1 failed") {
+		t.Error("session list should show the failure count")
+	}
+}
+
+func TestHandleSession_Timeline(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+	srv.Location = time.UTC
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-Users-foo-workspace-proj/sess-1", nil))
+	body := w.Body.String()
+	for _, want := range []string{`>2026-02-25 06:41</time> &ndash; <time datetime="2026-02-25T06:42:02Z"`, "active 6s", `class="seg seg-generation"`, "flex-grow: 6.273"} {
+		if !containsString(body, want) {
+			t.Errorf("session page should contain %q", want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-Users-foo-workspace-proj/sess-1?idle=soon", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid idle status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleSession_Context(t *testing.T) {
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-work-app")
+	os.MkdirAll(projDir, 0755)
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55Z","sessionId":"sess-1","message":{"role":"user","content":"Read it"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:00Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/work/app/big.go"}}],"usage":{"input_tokens":10,"output_tokens":100,"cache_creation_input_tokens":0,"cache_read_input_tokens":40000}}}
+{"type":"user","uuid":"r1","timestamp":"2026-02-25T06:42:01Z","sessionId":"sess-1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"package big"}]}}
+{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T06:42:05Z","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"text","text":"Big file"}],"usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":60000,"cache_read_input_tokens":40000}}}
+{"type":"system","subtype":"compact_boundary","uuid":"c1","timestamp":"2026-02-25T06:50:00Z","sessionId":"sess-1","content":"Conversation compacted","compactMetadata":{"trigger":"manual","preTokens":100030}}
+`
+	os.WriteFile(filepath.Join(projDir, "sess-1.jsonl"), []byte(content), 0644)
+	srv := New(dir, openTestStore(t))
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-work-app/sess-1", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		"Context: peak 100k of 200k (50%)",
+		"in 10 &middot; out 20 &middot; cache 40k/60k &middot; ctx 50%",
+		`href="#msg-r1">Read</a> ~59.9k tokens`,
+		"Context compacted (manual, 100k tokens before)",
+	} {
+		if !containsString(body, want) {
+			t.Errorf("session page should contain %q", want)
+		}
+	}
+}
+
+func TestHandleSession_Models(t *testing.T) {
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-work-app")
+	os.MkdirAll(projDir, 0755)
+	content := `{"type":"user","uuid":"u1","timestamp":"2026-02-25T06:41:55Z","sessionId":"sess-1","message":{"role":"user","content":"Plan it"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-02-25T06:42:00Z","sessionId":"sess-1","message":{"model":"claude-opus-4-6","role":"assistant","content":[{"type":"text","text":"Plan"}]}}
+{"type":"assistant","uuid":"a2","timestamp":"2026-02-25T06:43:05Z","sessionId":"sess-1","message":{"model":"claude-haiku-4-5","role":"assistant","content":[{"type":"text","text":"Done"}]}}
+`
+	os.WriteFile(filepath.Join(projDir, "sess-1.jsonl"), []byte(content), 0644)
+	srv := New(dir, openTestStore(t))
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-work-app/sess-1", nil))
+	body := w.Body.String()
+	for _, want := range []string{`Switched to <span class="model-label">claude-haiku-4-5</span>`, `<span class="model-label">claude-opus-4-6</span> 1 message,`} {
+		if !containsString(body, want) {
+			t.Errorf("session page should contain %q", want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/projects/-work-app", nil))
+	if !containsString(w.Body.String(), "claude-opus-4-6, claude-haiku-4-5") {
+		t.Error("session list should show every model used")
+	}
+}
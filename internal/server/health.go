@@ -0,0 +1,35 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// handleHealthz reports that the process is up and serving.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz reports whether the server can serve pages: the log and
+// data directories must be readable.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := s.ready(); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "not ready: %v\n", err)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *Server) ready() error {
+	if _, err := os.ReadDir(s.LogDir); err != nil {
+		return fmt.Errorf("log dir: %w", err)
+	}
+	if _, err := os.ReadDir(s.Store.Dir()); err != nil {
+		return fmt.Errorf("data dir: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency
+// histogram.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metrics counts requests for the /metrics endpoint. Requests are labelled
+// by route pattern rather than path, so the number of series stays small.
+type metrics struct {
+	mu       sync.Mutex
+	requests map[requestKey]int64
+	latency  map[string]*histogram // By route
+}
+
+type requestKey struct {
+	method, route string
+	code          int
+}
+
+type histogram struct {
+	counts []int64 // Per bucket, plus one for +Inf
+	sum    float64
+	count  int64
+}
+
+func newMetrics() *metrics {
+	return &metrics{requests: make(map[requestKey]int64), latency: make(map[string]*histogram)}
+}
+
+func (m *metrics) observe(method, route string, code int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{method, route, code}]++
+	h := m.latency[route]
+	if h == nil {
+		h = &histogram{counts: make([]int64, len(latencyBuckets)+1)}
+		m.latency[route] = h
+	}
+	sec := d.Seconds()
+	i := sort.SearchFloat64s(latencyBuckets, sec)
+	h.counts[i]++
+	h.sum += sec
+	h.count++
+}
+
+// write prints the request metrics in the Prometheus text format.
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+	writeHeader(w, "ccs_http_requests_total", "counter", "HTTP requests by method, route and status code.")
+	for _, k := range keys {
+		fmt.Fprintf(w, "ccs_http_requests_total{method=%s,route=%s,code=\"%d\"} %d\n", label(k.method), label(k.route), k.code, m.requests[k])
+	}
+
+	routes := make([]string, 0, len(m.latency))
+	for r := range m.latency {
+		routes = append(routes, r)
+	}
+	sort.Strings(routes)
+	writeHeader(w, "ccs_http_request_duration_seconds", "histogram", "HTTP request latency by route.")
+	for _, r := range routes {
+		h := m.latency[r]
+		var cum int64
+		for i, le := range latencyBuckets {
+			cum += h.counts[i]
+			fmt.Fprintf(w, "ccs_http_request_duration_seconds_bucket{route=%s,le=\"%s\"} %d\n", label(r), strconv.FormatFloat(le, 'g', -1, 64), cum)
+		}
+		fmt.Fprintf(w, "ccs_http_request_duration_seconds_bucket{route=%s,le=\"+Inf\"} %d\n", label(r), h.count)
+		fmt.Fprintf(w, "ccs_http_request_duration_seconds_sum{route=%s} %g\n", label(r), h.sum)
+		fmt.Fprintf(w, "ccs_http_request_duration_seconds_count{route=%s} %d\n", label(r), h.count)
+	}
+}
+
+func writeHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func label(s string) string {
+	return strconv.Quote(s)
+}
+
+// handleMetrics serves request, parser and cache metrics in the Prometheus
+// text format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.write(w)
+
+	pe := logparser.ParseErrors()
+	writeHeader(w, "ccs_parse_errors", "gauge", "Log lines skipped because they could not be parsed, in the files parsed so far.")
+	fmt.Fprintf(w, "ccs_parse_errors{kind=\"malformed_line\"} %d\n", pe.MalformedLines)
+	fmt.Fprintf(w, "ccs_parse_errors{kind=\"content\"} %d\n", pe.ContentErrors)
+
+	writeHeader(w, "ccs_index_sessions", "gauge", "Sessions in the session index.")
+	fmt.Fprintf(w, "ccs_index_sessions %d\n", s.Index.Len())
+
+	cs, cc := s.Index.CacheStats(), s.Conversations.CacheStats()
+	writeHeader(w, "ccs_cache_hits_total", "counter", "Lookups served from a cache.")
+	fmt.Fprintf(w, "ccs_cache_hits_total{cache=\"session_index\"} %d\n", cs.Hits)
+	fmt.Fprintf(w, "ccs_cache_hits_total{cache=\"conversations\"} %d\n", cc.Hits)
+	writeHeader(w, "ccs_cache_misses_total", "counter", "Lookups that missed a cache and parsed the log file.")
+	fmt.Fprintf(w, "ccs_cache_misses_total{cache=\"session_index\"} %d\n", cs.Misses)
+	fmt.Fprintf(w, "ccs_cache_misses_total{cache=\"conversations\"} %d\n", cc.Misses)
+	writeHeader(w, "ccs_cache_appends_total", "counter", "Cache misses that only parsed the lines appended to a log file.")
+	fmt.Fprintf(w, "ccs_cache_appends_total{cache=\"conversations\"} %d\n", cc.Appends)
+	writeHeader(w, "ccs_cache_evictions_total", "counter", "Entries evicted from a cache to stay within its size.")
+	fmt.Fprintf(w, "ccs_cache_evictions_total{cache=\"conversations\"} %d\n", cc.Evictions)
+	writeHeader(w, "ccs_cache_entries", "gauge", "Entries in a cache.")
+	fmt.Fprintf(w, "ccs_cache_entries{cache=\"conversations\"} %d\n", cc.Entries)
+	writeHeader(w, "ccs_cache_bytes", "gauge", "Estimated size of a cache's entries in bytes.")
+	fmt.Fprintf(w, "ccs_cache_bytes{cache=\"conversations\"} %d\n", cc.Bytes)
+}
+
+// statusRecorder remembers the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// probePaths are polled by monitoring and only logged at debug level.
+var probePaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// observe logs every request and records it in the metrics.
+func (s *Server) observe(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		d := time.Since(start)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		// The mux records the matched pattern on the request.
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		s.metrics.observe(r.Method, route, rec.status, d)
+
+		level := slog.LevelInfo
+		if probePaths[r.URL.Path] {
+			level = slog.LevelDebug
+		}
+		slog.Log(r.Context(), level, "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"latency", d,
+			"user", commentAuthor(r),
+		)
+	})
+}
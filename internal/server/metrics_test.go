@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+)
+
+func TestMetrics(t *testing.T) {
+	dir := setupTestLogDir(t)
+	ix, err := logparser.OpenIndex(filepath.Join(t.TempDir(), "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(dir, openTestStore(t))
+	srv.Index = ix
+	srv.Conversations = logparser.NewConversationCache(1 << 20)
+	h := srv.Handler()
+
+	for _, path := range []string{
+		"/", "/", "/projects/-Users-foo-workspace-proj", "/projects/-Users-foo-workspace-proj",
+		"/sessions/-Users-foo-workspace-proj/nope",
+		"/sessions/-Users-foo-workspace-proj/sess-1", "/sessions/-Users-foo-workspace-proj/sess-1",
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`ccs_http_requests_total{method="GET",route="/",code="200"} 2`,
+		`ccs_http_requests_total{method="GET",route="/projects/",code="200"} 2`,
+		`ccs_http_requests_total{method="GET",route="/sessions/",code="404"} 1`,
+		`ccs_http_request_duration_seconds_count{route="/"} 2`,
+		`ccs_http_request_duration_seconds_bucket{route="/",le="+Inf"} 2`,
+		`ccs_parse_errors{kind="malformed_line"}`,
+		"ccs_index_sessions 1\n",
+		`ccs_cache_hits_total{cache="session_index"} 1`,
+		`ccs_cache_misses_total{cache="session_index"} 1`,
+		`ccs_cache_hits_total{cache="conversations"} 1`,
+		`ccs_cache_misses_total{cache="conversations"} 1`,
+		`ccs_cache_entries{cache="conversations"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsCountsMalformedLines(t *testing.T) {
+	dir := setupTestLogDir(t)
+	f, err := os.OpenFile(filepath.Join(dir, "-Users-foo-workspace-proj", "sess-1.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{not json\n")
+	f.Close()
+
+	before := logparser.ParseErrors().MalformedLines
+	srv := New(dir, openTestStore(t))
+	// Parsing the file again, for the session list, does not count the
+	// line twice.
+	for _, path := range []string{"/sessions/-Users-foo-workspace-proj/sess-1", "/projects/-Users-foo-workspace-proj"} {
+		srv.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	if got := logparser.ParseErrors().MalformedLines - before; got != 1 {
+		t.Errorf("malformed lines counted = %d, want 1", got)
+	}
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+	h := srv.Handler()
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != "ok\n" {
+			t.Errorf("%s = %d %q, want 200 ok", path, w.Code, w.Body.String())
+		}
+	}
+
+	srv.LogDir = filepath.Join(dir, "missing")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "log dir") {
+		t.Errorf("/readyz with missing log dir = %d %q, want 503 naming the log dir", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+)
+
+// handleSessionPatch serves the net change of a session as a unified diff.
+// The optional from and to query parameters limit it to a message range.
+func (s *Server) handleSessionPatch(w http.ResponseWriter, r *http.Request) {
+	slug, sessionID := r.PathValue("slug"), r.PathValue("id")
+	v := s.newValidator()
+	v.session(s.LogDir, slug, sessionID)
+	if notModified(w, r, v) {
+		return
+	}
+	conv, err := s.Conversations.LoadSession(s.LogDir, slug, sessionID)
+	if err != nil {
+		slog.Error("failed to load session", "error", err, "slug", slug, "session", sessionID)
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	q := r.URL.Query()
+	patch, err := logparser.BuildPatch(s.LogDir, conv, logparser.PatchOptions{From: q.Get("from"), To: q.Get("to")})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v.setHeaders(w)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if q.Get("download") != "" {
+		w.Header().Set("Content-Disposition", `attachment; filename="`+sessionID+`.patch"`)
+	}
+	w.Write([]byte(patch.String()))
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/store"
+)
+
+const (
+	defaultPerPage = 50
+	maxPerPage     = 500
+)
+
+// listParams holds the query parameters shared by the project and session lists.
+type listParams struct {
+	Sort         string
+	Ascending    bool
+	Model        string
+	Since, Until time.Time
+	MinMessages  int
+	Page         int
+	PerPage      int
+}
+
+// parseListParams reads sort, order, model, since, until, min_messages,
+// page and per_page from the query string. Dates use YYYY-MM-DD and until
+// is inclusive.
+func parseListParams(q url.Values, sortKeys ...string) (listParams, error) {
+	p := listParams{
+		Sort:    q.Get("sort"),
+		Model:   q.Get("model"),
+		Page:    1,
+		PerPage: defaultPerPage,
+	}
+	if p.Sort == "" {
+		p.Sort = logparser.SortActivity
+	}
+	if !slices.Contains(sortKeys, p.Sort) {
+		return p, fmt.Errorf("invalid sort %q", p.Sort)
+	}
+
+	switch q.Get("order") {
+	case "":
+		// Names read naturally A-Z; everything else is most/newest first.
+		p.Ascending = p.Sort == logparser.SortName
+	case "asc":
+		p.Ascending = true
+	case "desc":
+		p.Ascending = false
+	default:
+		return p, fmt.Errorf("invalid order %q", q.Get("order"))
+	}
+
+	var err error
+	if p.Since, err = parseDate(q.Get("since")); err != nil {
+		return p, fmt.Errorf("invalid since: %w", err)
+	}
+	if p.Until, err = parseDate(q.Get("until")); err != nil {
+		return p, fmt.Errorf("invalid until: %w", err)
+	}
+	if !p.Until.IsZero() {
+		p.Until = p.Until.AddDate(0, 0, 1)
+	}
+	if p.MinMessages, err = parseInt(q.Get("min_messages"), 0); err != nil {
+		return p, fmt.Errorf("invalid min_messages: %w", err)
+	}
+	if p.Page, err = parseInt(q.Get("page"), 1); err != nil {
+		return p, fmt.Errorf("invalid page: %w", err)
+	}
+	if p.PerPage, err = parseInt(q.Get("per_page"), defaultPerPage); err != nil {
+		return p, fmt.Errorf("invalid per_page: %w", err)
+	}
+	p.PerPage = max(1, min(p.PerPage, maxPerPage))
+	return p, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func parseInt(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a non-negative integer", s)
+	}
+	return n, nil
+}
+
+// queryProjects returns the page of projects selected by the request's
+// query parameters.
+func (s *Server) queryProjects(r *http.Request) (logparser.Page[logparser.Project], error) {
+	projects, p, err := s.filterProjects(r)
+	if err != nil {
+		return logparser.Page[logparser.Project]{}, err
+	}
+	return logparser.Paginate(projects, p.Page, p.PerPage), nil
+}
+
+// filterProjects returns all projects selected by the request's query
+// parameters, sorted, along with the parameters for paging them.
+func (s *Server) filterProjects(r *http.Request) ([]logparser.Project, listParams, error) {
+	q := r.URL.Query()
+	p, err := parseListParams(q,
+		logparser.SortActivity, logparser.SortSessions, logparser.SortTokens,
+		logparser.SortCost, logparser.SortName)
+	if err != nil {
+		return nil, p, badRequest{err}
+	}
+	projects, err := s.Index.QueryProjects(r.Context(), s.LogDir, logparser.ProjectQuery{
+		Sort:         p.Sort,
+		Ascending:    p.Ascending,
+		Model:        p.Model,
+		Since:        p.Since,
+		Until:        p.Until,
+		PathContains: q.Get("path"),
+		MinMessages:  p.MinMessages,
+	})
+	if err != nil {
+		return nil, p, err
+	}
+	s.saveIndex()
+	return projects, p, nil
+}
+
+// querySessions returns the page of a project's sessions selected by the
+// request's query parameters, including the tag filter.
+func (s *Server) querySessions(r *http.Request, slug string) (logparser.Page[sessionItem], error) {
+	q := r.URL.Query()
+	p, err := parseListParams(q,
+		logparser.SortActivity, logparser.SortMessages, logparser.SortTokens, logparser.SortCost,
+		logparser.SortFailures)
+	if err != nil {
+		return logparser.Page[sessionItem]{}, badRequest{err}
+	}
+	sessions, err := s.Index.QuerySessions(r.Context(), s.LogDir, slug, logparser.SessionQuery{
+		Sort:        p.Sort,
+		Ascending:   p.Ascending,
+		Model:       p.Model,
+		Branch:      q.Get("branch"),
+		Since:       p.Since,
+		Until:       p.Until,
+		MinMessages: p.MinMessages,
+	})
+	if err != nil {
+		return logparser.Page[sessionItem]{}, err
+	}
+	s.saveIndex()
+
+	items := s.decorate(sessions)
+	if tag := store.NormalizeTag(q.Get("tag")); tag != "" {
+		filtered := items[:0]
+		for _, it := range items {
+			if slices.Contains(it.Meta.Tags, tag) {
+				filtered = append(filtered, it)
+			}
+		}
+		items = filtered
+	}
+	return logparser.Paginate(items, p.Page, p.PerPage), nil
+}
+
+// saveIndex persists any summaries added to the session index.
+func (s *Server) saveIndex() {
+	if err := s.Index.Save(); err != nil {
+		slog.Warn("failed to save session index", "error", err)
+	}
+}
+
+// badRequest marks errors caused by invalid query parameters.
+type badRequest struct{ error }
+
+// pager is the template data for previous/next page links.
+type pager struct {
+	Page, Pages, Total int
+	PrevURL, NextURL   string
+}
+
+func newPager[T any](p logparser.Page[T], u *url.URL) pager {
+	link := func(page int) string {
+		q := u.Query()
+		q.Set("page", strconv.Itoa(page))
+		return u.Path + "?" + q.Encode()
+	}
+	pg := pager{Page: p.Page, Pages: p.Pages, Total: p.Total}
+	if p.Page > 1 {
+		pg.PrevURL = link(p.Page - 1)
+	}
+	if p.Page < p.Pages {
+		pg.NextURL = link(p.Page + 1)
+	}
+	return pg
+}
@@ -3,33 +3,67 @@ package server
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"log/slog"
+	"mime"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/yuin/goldmark"
 
 	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/store"
 	"github.com/nhosoya/claude-code-share/internal/templates"
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
 )
 
 // Server holds the HTTP server configuration.
 type Server struct {
 	LogDir string
-	pages  map[string]*template.Template
+	Store  *store.Store
+	// Location is the default time zone for rendering times; viewers can
+	// override it with ?tz=. Nil means the server's local time zone.
+	Location *time.Location
+	// Index caches session summaries between requests. Nil parses every
+	// session each time.
+	Index *logparser.SessionIndex
+	// Conversations caches parsed sessions between requests. Nil parses
+	// the session on every request.
+	Conversations *logparser.ConversationCache
+	pages         map[string]*template.Template
+	metrics       *metrics
+	started       time.Time
 }
 
-// New creates a new Server with parsed templates.
-func New(logDir string) *Server {
+// New creates a new Server with parsed templates. The store holds
+// user-generated data such as comments.
+func New(logDir string, st *store.Store) *Server {
 	funcMap := template.FuncMap{
 		"formatToolInput": formatToolInput,
 		"hasText":         hasText,
+		"isInterrupt":     logparser.IsInterrupt,
 		"renderMarkdown":  renderMarkdown,
+		"thread":          newThread,
+		"truncate":        truncate,
+		"formatCost":      formatCost,
+		"renderDiff":      renderDiff,
+		"formatDuration":  formatDuration,
+		"formatTokens":    formatTokens,
+	}
+	// Placeholders so the pages parse; render binds the viewer's time zone.
+	for name, fn := range timeFuncs(time.Local, time.Now()) {
+		funcMap[name] = fn
 	}
 
 	// Parse each page template together with the layout so that
 	// "title" and "content" blocks don't collide across pages.
-	pageNames := []string{"index.html", "project.html", "session.html"}
+	pageNames := []string{
+		"index.html", "project.html", "session.html",
+		"collections.html", "collection.html", "tag.html", "files.html",
+		"diagnostics.html",
+	}
 	pages := make(map[string]*template.Template, len(pageNames))
 	for _, name := range pageNames {
 		pages[name] = template.Must(
@@ -38,38 +72,109 @@ func New(logDir string) *Server {
 	}
 
 	return &Server{
-		LogDir: logDir,
-		pages:  pages,
+		LogDir:  logDir,
+		Store:   st,
+		pages:   pages,
+		metrics: newMetrics(),
+		started: time.Now(),
 	}
 }
 
-// Handler returns an http.Handler with all routes configured.
+// Handler returns an http.Handler with all routes configured. Every
+// request is logged and counted in the metrics, and large text responses
+// are compressed.
 func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleIndex)
 	mux.HandleFunc("/projects/", s.handleProject)
+	mux.HandleFunc("GET /projects/{slug}/files", s.handleProjectFiles)
 	mux.HandleFunc("/sessions/", s.handleSession)
-	return mux
+	mux.HandleFunc("GET /sessions/{slug}/{id}/patch", s.handleSessionPatch)
+
+	mux.HandleFunc("GET /collections", s.handleCollections)
+	mux.HandleFunc("GET /collections/{collection}", s.handleCollection)
+	mux.HandleFunc("GET /tags/{tag}", s.handleTag)
+	mux.HandleFunc("GET /diagnostics", s.handleDiagnostics)
+
+	mux.HandleFunc("GET /api/projects", s.handleAPIProjects)
+	mux.HandleFunc("GET /api/projects/{slug}/sessions", s.handleAPISessions)
+	mux.HandleFunc("GET /api/sessions/{slug}/{id}/entries", s.handleAPIEntries)
+	mux.HandleFunc("GET /api/sessions/{slug}/{id}/comments", s.handleListComments)
+	mux.HandleFunc("POST /api/sessions/{slug}/{id}/comments", s.handleAddComment)
+	mux.HandleFunc("PUT /api/sessions/{slug}/{id}/comments/{commentID}", s.handleUpdateComment)
+	mux.HandleFunc("DELETE /api/sessions/{slug}/{id}/comments/{commentID}", s.handleDeleteComment)
+	mux.HandleFunc("PUT /api/sessions/{slug}/{id}/star", s.handleSetStar)
+	mux.HandleFunc("PUT /api/sessions/{slug}/{id}/tags", s.handleSetTags)
+	mux.HandleFunc("GET /api/collections", s.handleListCollections)
+	mux.HandleFunc("POST /api/collections", s.handleCreateCollection)
+	mux.HandleFunc("PUT /api/collections/{collection}", s.handleUpdateCollection)
+	mux.HandleFunc("DELETE /api/collections/{collection}", s.handleDeleteCollection)
+	mux.HandleFunc("POST /api/collections/{collection}/sessions", s.handleAddToCollection)
+	mux.HandleFunc("DELETE /api/collections/{collection}/sessions/{slug}/{id}", s.handleRemoveFromCollection)
+
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	return s.observe(compress(mux))
 }
 
-func (s *Server) render(w http.ResponseWriter, page string, data interface{}) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+// render executes a page with times shown in the viewer's time zone.
+func (s *Server) render(w http.ResponseWriter, r *http.Request, page string, data interface{}) {
 	tmpl := s.pages[page]
 	if tmpl == nil {
 		slog.Error("template not found", "page", page)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		slog.Error("template clone error", "page", page, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	tmpl.Funcs(timeFuncs(s.location(r), time.Now()))
+
+	rememberLocation(w, r)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := tmpl.ExecuteTemplate(w, "layout", data); err != nil {
 		slog.Error("template render error", "page", page, "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
 
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("json encode error", "error", err)
+	}
+}
+
+// decodeJSON reads a small JSON request body into v, reporting whether it
+// succeeded; if not, it has replied with an error. Only application/json
+// is accepted, which browsers do not send across origins without a
+// preflight request, so other sites cannot post comments or change
+// collections through a plain form.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); t != "application/json" {
+		writeJSONError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+		return false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(v); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
 // hasText returns true if an assistant message contains at least one text block.
-func hasText(blocks []logparser.ContentBlock) bool {
+func hasText(blocks []claudelog.Block) bool {
 	for _, b := range blocks {
-		if b.Type == "text" && b.Text != "" {
+		if t, ok := b.(*claudelog.TextBlock); ok && t.Text != "" {
 			return true
 		}
 	}
@@ -84,10 +189,76 @@ func renderMarkdown(s string) template.HTML {
 	return template.HTML(buf.String())
 }
 
-func formatToolInput(input map[string]interface{}) string {
-	b, err := json.MarshalIndent(input, "", "  ")
-	if err != nil {
+func formatToolInput(input json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, input, "", "  "); err != nil {
 		return "{}"
 	}
-	return string(b)
+	return buf.String()
+}
+
+// renderDiff wraps each line of a unified diff in a span classed by its
+// kind, for highlighting.
+func renderDiff(d string) template.HTML {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(d, "\n") {
+		if line == "" {
+			continue
+		}
+		class := "diff-ctx"
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			class = "diff-file"
+		case strings.HasPrefix(line, "@@"):
+			class = "diff-hunk"
+		case strings.HasPrefix(line, "+"):
+			class = "diff-add"
+		case strings.HasPrefix(line, "-"):
+			class = "diff-del"
+		}
+		fmt.Fprintf(&b, `<span class="%s">%s</span>`, class, template.HTMLEscapeString(line))
+	}
+	return template.HTML(b.String())
+}
+
+func formatCost(usd float64) string {
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+// formatDuration renders a duration coarsely, e.g. "1h 5m", "3m 20s" or "12s".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, sec := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh %dm", h, m)
+	case m > 0:
+		return fmt.Sprintf("%dm %ds", m, sec)
+	default:
+		return fmt.Sprintf("%ds", sec)
+	}
+}
+
+// formatTokens renders a token count compactly, e.g. "850", "12.3k" or
+// "1M".
+func formatTokens(n int) string {
+	scaled := func(v float64, unit string) string {
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", v), ".0") + unit
+	}
+	switch {
+	case n >= 1_000_000:
+		return scaled(float64(n)/1e6, "M")
+	case n >= 1000:
+		return scaled(float64(n)/1e3, "k")
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max]) + "..."
 }
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+// tzCookie remembers the time zone a viewer picked with ?tz=.
+const tzCookie = "ccs_tz"
+
+// location returns the time zone to render times in for this request: the
+// tz query parameter, then the viewer's cookie, then the server default.
+func (s *Server) location(r *http.Request) *time.Location {
+	if loc, ok := loadLocation(r.URL.Query().Get("tz")); ok {
+		return loc
+	}
+	if c, err := r.Cookie(tzCookie); err == nil {
+		if loc, ok := loadLocation(c.Value); ok {
+			return loc
+		}
+	}
+	if s.Location != nil {
+		return s.Location
+	}
+	return time.Local
+}
+
+// rememberLocation stores a valid ?tz= choice in a cookie. An empty tz
+// parameter clears it.
+func rememberLocation(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if !q.Has("tz") {
+		return
+	}
+	tz := q.Get("tz")
+	if tz == "" {
+		http.SetCookie(w, &http.Cookie{Name: tzCookie, Path: "/", MaxAge: -1})
+		return
+	}
+	if _, ok := loadLocation(tz); ok {
+		http.SetCookie(w, &http.Cookie{Name: tzCookie, Value: tz, Path: "/", MaxAge: 365 * 24 * 3600, SameSite: http.SameSiteLaxMode})
+	}
+}
+
+func loadLocation(name string) (*time.Location, bool) {
+	if name == "" {
+		return nil, false
+	}
+	loc, err := time.LoadLocation(name)
+	return loc, err == nil
+}
+
+// timeFuncs returns the template functions that render times in loc.
+func timeFuncs(loc *time.Location, now time.Time) template.FuncMap {
+	return template.FuncMap{
+		"timeTag": func(t time.Time, layout string) template.HTML {
+			return timeElement(t, loc, t.In(loc).Format(layout))
+		},
+		"localTime": func(t time.Time, layout string) string {
+			return t.In(loc).Format(layout)
+		},
+		"ago": func(t time.Time) template.HTML {
+			return timeElement(t, loc, relativeTime(t.In(loc), now))
+		},
+		"tzName": func() string {
+			return loc.String()
+		},
+	}
+}
+
+// timeElement renders a <time> element with a machine-readable datetime and
+// the full local time as tooltip.
+func timeElement(t time.Time, loc *time.Location, text string) template.HTML {
+	if t.IsZero() {
+		return ""
+	}
+	local := t.In(loc)
+	return template.HTML(fmt.Sprintf(`<time datetime="%s" title="%s">%s</time>`,
+		t.UTC().Format(time.RFC3339),
+		template.HTMLEscapeString(local.Format("2006-01-02 15:04:05 MST")),
+		template.HTMLEscapeString(text)))
+}
+
+// relativeTime describes t relative to now, e.g. "3h ago". Times more than
+// a month old are shown as dates.
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	default:
+		return t.Format("2006-01-02")
+	}
+}
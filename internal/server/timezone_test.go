@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandleSession_TimeZone(t *testing.T) {
+	dir := setupTestLogDir(t)
+	srv := New(dir, openTestStore(t))
+	srv.Location = time.UTC
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-Users-foo-workspace-proj/sess-1?tz=Asia/Tokyo", nil))
+	body := w.Body.String()
+	for _, want := range []string{`<time datetime="2026-02-25T06:41:55Z" title="2026-02-25 15:41:55 JST">15:41</time>`, "Times shown in Asia/Tokyo"} {
+		if !containsString(body, want) {
+			t.Errorf("session page should contain %q", want)
+		}
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != tzCookie || cookies[0].Value != "Asia/Tokyo" {
+		t.Fatalf("cookies = %v, want %s=Asia/Tokyo", cookies, tzCookie)
+	}
+
+	// The cookie is honored on later requests.
+	r := httptest.NewRequest("GET", "/sessions/-Users-foo-workspace-proj/sess-1", nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, r)
+	if !containsString(w.Body.String(), ">15:41</time>") {
+		t.Error("cookie time zone should be used")
+	}
+
+	// An empty tz clears the cookie and falls back to the server default.
+	r = httptest.NewRequest("GET", "/sessions/-Users-foo-workspace-proj/sess-1?tz=", nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, r)
+	if c := w.Result().Cookies(); len(c) != 1 || c[0].MaxAge >= 0 {
+		t.Errorf("cookies = %v, want %s cleared", c, tzCookie)
+	}
+
+	// Unknown zones are ignored.
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sessions/-Users-foo-workspace-proj/sess-1?tz=Mars/Olympus", nil))
+	if w.Code != http.StatusOK || !containsString(w.Body.String(), ">06:41</time>") {
+		t.Errorf("unknown time zone should fall back to the default, status = %d", w.Code)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("unknown time zone should not be remembered")
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now.Add(-10 * time.Second), "just now"},
+		{now.Add(-5 * time.Minute), "5m ago"},
+		{now.Add(-3 * time.Hour), "3h ago"},
+		{now.Add(-50 * time.Hour), "2d ago"},
+		{now.Add(-40 * 24 * time.Hour), "2026-01-20"},
+	}
+	for _, tt := range tests {
+		if got := relativeTime(tt.t, now); got != tt.want {
+			t.Errorf("relativeTime(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Comment is a note attached to a single message within a session.
+type Comment struct {
+	ID          string    `json:"id"`
+	Slug        string    `json:"slug"`
+	SessionID   string    `json:"sessionId"`
+	MessageUUID string    `json:"messageUuid"`
+	Author      string    `json:"author"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt,omitempty"`
+}
+
+func (s *Store) commentsPath(sessionID string) string {
+	return filepath.Join(s.dir, "comments", sessionID+".json")
+}
+
+func (s *Store) loadComments(sessionID string) ([]Comment, error) {
+	if !validKey(sessionID) {
+		return nil, invalidf("invalid session id %q", sessionID)
+	}
+	var comments []Comment
+	if err := readJSON(s.commentsPath(sessionID), &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// ListComments returns all comments for a session, oldest first.
+func (s *Store) ListComments(sessionID string) ([]Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadComments(sessionID)
+}
+
+// AddComment stores a new comment and returns it with ID and timestamps set.
+func (s *Store) AddComment(c Comment) (Comment, error) {
+	c.Body = strings.TrimSpace(c.Body)
+	if c.Body == "" {
+		return Comment{}, invalidf("comment body is empty")
+	}
+	if c.MessageUUID == "" {
+		return Comment{}, invalidf("comment message uuid is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comments, err := s.loadComments(c.SessionID)
+	if err != nil {
+		return Comment{}, err
+	}
+	c.ID = newID()
+	c.CreatedAt = time.Now().UTC()
+	c.UpdatedAt = time.Time{}
+	comments = append(comments, c)
+	if err := s.writeJSON(s.commentsPath(c.SessionID), comments); err != nil {
+		return Comment{}, err
+	}
+	return c, nil
+}
+
+// UpdateComment replaces the body of an existing comment. Only the original
+// author may edit a comment.
+func (s *Store) UpdateComment(sessionID, id, author, body string) (Comment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return Comment{}, invalidf("comment body is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comments, err := s.loadComments(sessionID)
+	if err != nil {
+		return Comment{}, err
+	}
+	for i := range comments {
+		if comments[i].ID != id {
+			continue
+		}
+		if comments[i].Author != author {
+			return Comment{}, ErrForbidden
+		}
+		comments[i].Body = body
+		comments[i].UpdatedAt = time.Now().UTC()
+		if err := s.writeJSON(s.commentsPath(sessionID), comments); err != nil {
+			return Comment{}, err
+		}
+		return comments[i], nil
+	}
+	return Comment{}, ErrNotFound
+}
+
+// DeleteComment removes a comment. Only the original author may delete it.
+func (s *Store) DeleteComment(sessionID, id, author string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comments, err := s.loadComments(sessionID)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		if comments[i].ID != id {
+			continue
+		}
+		if comments[i].Author != author {
+			return ErrForbidden
+		}
+		comments = append(comments[:i], comments[i+1:]...)
+		return s.writeJSON(s.commentsPath(sessionID), comments)
+	}
+	return ErrNotFound
+}
+
+// RecentComments returns up to limit comments across all sessions, newest first.
+func (s *Store) RecentComments(limit int) ([]Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "comments", "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("glob comments: %w", err)
+	}
+
+	var all []Comment
+	for _, f := range files {
+		var comments []Comment
+		if err := readJSON(f, &comments); err != nil {
+			return nil, err
+		}
+		all = append(all, comments...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].CreatedAt.After(all[j].CreatedAt)
+	})
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddAndListComments(t *testing.T) {
+	st, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := st.AddComment(Comment{Slug: "-proj", SessionID: "sess-1", MessageUUID: "u1", Author: "alice", Body: " Nice prompt "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.ID == "" {
+		t.Error("ID should be set")
+	}
+	if c.Body != "Nice prompt" {
+		t.Errorf("Body = %q, want %q", c.Body, "Nice prompt")
+	}
+
+	comments, err := st.ListComments("sess-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(comments) != 1 {
+		t.Fatalf("comments length = %d, want 1", len(comments))
+	}
+	if comments[0].MessageUUID != "u1" {
+		t.Errorf("MessageUUID = %q, want %q", comments[0].MessageUUID, "u1")
+	}
+}
+
+func TestAddComment_Invalid(t *testing.T) {
+	st, _ := Open(t.TempDir())
+	if _, err := st.AddComment(Comment{SessionID: "sess-1", MessageUUID: "u1", Body: "  "}); err == nil {
+		t.Error("expected error for empty body")
+	}
+	if _, err := st.AddComment(Comment{SessionID: "../etc", MessageUUID: "u1", Body: "x"}); err == nil {
+		t.Error("expected error for path-like session id")
+	}
+}
+
+func TestUpdateAndDeleteComment(t *testing.T) {
+	st, _ := Open(t.TempDir())
+	c, _ := st.AddComment(Comment{SessionID: "sess-1", MessageUUID: "u1", Author: "alice", Body: "first"})
+
+	if _, err := st.UpdateComment("sess-1", c.ID, "bob", "hijack"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("UpdateComment by other author: err = %v, want ErrForbidden", err)
+	}
+	updated, err := st.UpdateComment("sess-1", c.ID, "alice", "edited")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Body != "edited" || updated.UpdatedAt.IsZero() {
+		t.Errorf("updated = %+v, want body %q with UpdatedAt set", updated, "edited")
+	}
+
+	if err := st.DeleteComment("sess-1", "missing", "alice"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteComment missing: err = %v, want ErrNotFound", err)
+	}
+	if err := st.DeleteComment("sess-1", c.ID, "alice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	comments, _ := st.ListComments("sess-1")
+	if len(comments) != 0 {
+		t.Errorf("comments length = %d, want 0", len(comments))
+	}
+}
+
+func TestRecentComments(t *testing.T) {
+	st, _ := Open(t.TempDir())
+	st.AddComment(Comment{SessionID: "sess-1", MessageUUID: "u1", Author: "alice", Body: "one"})
+	st.AddComment(Comment{SessionID: "sess-2", MessageUUID: "u2", Author: "bob", Body: "two"})
+	st.AddComment(Comment{SessionID: "sess-1", MessageUUID: "u3", Author: "bob", Body: "three"})
+
+	recent, err := st.RecentComments(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recent) != 2 {
+		t.Fatalf("recent length = %d, want 2", len(recent))
+	}
+	if recent[0].Body != "three" {
+		t.Errorf("recent[0].Body = %q, want %q", recent[0].Body, "three")
+	}
+}
@@ -0,0 +1,354 @@
+package store
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SessionRef identifies a session within a project.
+type SessionRef struct {
+	Slug      string `json:"slug"`
+	SessionID string `json:"sessionId"`
+}
+
+// valid reports whether both parts of the reference are safe to use as
+// file name components, as they end up in log file paths.
+func (r SessionRef) valid() bool {
+	return validKey(r.Slug) && validKey(r.SessionID)
+}
+
+// SessionMeta holds curation data attached to a session.
+type SessionMeta struct {
+	SessionRef
+	Starred bool     `json:"starred,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// Collection is a named, curated list of sessions.
+type Collection struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Sessions    []SessionRef `json:"sessions"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// TagCount is a tag together with the number of sessions carrying it.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// metadata is the on-disk layout of meta.json.
+type metadata struct {
+	Sessions    map[string]*SessionMeta `json:"sessions"`
+	Collections []Collection            `json:"collections"`
+}
+
+func (s *Store) metaPath() string {
+	return filepath.Join(s.dir, "meta.json")
+}
+
+func (s *Store) loadMeta() (*metadata, error) {
+	m := &metadata{}
+	if err := readJSON(s.metaPath(), m); err != nil {
+		return nil, err
+	}
+	if m.Sessions == nil {
+		m.Sessions = make(map[string]*SessionMeta)
+	}
+	return m, nil
+}
+
+// updateMeta loads the metadata, applies fn and writes the result back.
+func (s *Store) updateMeta(fn func(m *metadata) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.loadMeta()
+	if err != nil {
+		return err
+	}
+	if err := fn(m); err != nil {
+		return err
+	}
+	// Drop entries that no longer carry any data.
+	for id, sm := range m.Sessions {
+		if !sm.Starred && len(sm.Tags) == 0 {
+			delete(m.Sessions, id)
+		}
+	}
+	return s.writeJSON(s.metaPath(), m)
+}
+
+func (s *Store) readMeta() (*metadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadMeta()
+}
+
+func (m *metadata) session(ref SessionRef) *SessionMeta {
+	sm := m.Sessions[ref.SessionID]
+	if sm == nil {
+		sm = &SessionMeta{SessionRef: ref}
+		m.Sessions[ref.SessionID] = sm
+	}
+	return sm
+}
+
+// SessionMeta returns the curation data for a session. Unknown sessions
+// return a zero value with only the ID set.
+func (s *Store) SessionMeta(sessionID string) (SessionMeta, error) {
+	m, err := s.readMeta()
+	if err != nil {
+		return SessionMeta{}, err
+	}
+	if sm := m.Sessions[sessionID]; sm != nil {
+		return *sm, nil
+	}
+	return SessionMeta{SessionRef: SessionRef{SessionID: sessionID}}, nil
+}
+
+// AllSessionMeta returns the curation data for every annotated session,
+// keyed by session ID.
+func (s *Store) AllSessionMeta() (map[string]SessionMeta, error) {
+	m, err := s.readMeta()
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]SessionMeta, len(m.Sessions))
+	for id, sm := range m.Sessions {
+		all[id] = *sm
+	}
+	return all, nil
+}
+
+// SetStarred stars or unstars a session.
+func (s *Store) SetStarred(ref SessionRef, starred bool) error {
+	if !ref.valid() {
+		return invalidf("invalid session reference %q/%q", ref.Slug, ref.SessionID)
+	}
+	return s.updateMeta(func(m *metadata) error {
+		m.session(ref).Starred = starred
+		return nil
+	})
+}
+
+// SetTags replaces the tags on a session. Tags are normalized with NormalizeTag
+// and deduplicated.
+func (s *Store) SetTags(ref SessionRef, tags []string) ([]string, error) {
+	if !ref.valid() {
+		return nil, invalidf("invalid session reference %q/%q", ref.Slug, ref.SessionID)
+	}
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, t := range tags {
+		t = NormalizeTag(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		normalized = append(normalized, t)
+	}
+	sort.Strings(normalized)
+
+	err := s.updateMeta(func(m *metadata) error {
+		m.session(ref).Tags = normalized
+		return nil
+	})
+	return normalized, err
+}
+
+// SessionsWithTag returns all sessions carrying tag.
+func (s *Store) SessionsWithTag(tag string) ([]SessionMeta, error) {
+	m, err := s.readMeta()
+	if err != nil {
+		return nil, err
+	}
+	tag = NormalizeTag(tag)
+	var out []SessionMeta
+	for _, sm := range m.Sessions {
+		for _, t := range sm.Tags {
+			if t == tag {
+				out = append(out, *sm)
+				break
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SessionID < out[j].SessionID })
+	return out, nil
+}
+
+// Tags returns every tag in use with its session count, most used first.
+func (s *Store) Tags() ([]TagCount, error) {
+	m, err := s.readMeta()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, sm := range m.Sessions {
+		for _, t := range sm.Tags {
+			counts[t]++
+		}
+	}
+	out := make([]TagCount, 0, len(counts))
+	for t, n := range counts {
+		out = append(out, TagCount{Tag: t, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	return out, nil
+}
+
+// NormalizeTag lowercases a tag and replaces whitespace with hyphens.
+func NormalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	return strings.Join(strings.FieldsFunc(tag, unicode.IsSpace), "-")
+}
+
+// collectionID derives a URL-safe identifier from a collection name.
+func collectionID(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// Collections returns all collections in creation order.
+func (s *Store) Collections() ([]Collection, error) {
+	m, err := s.readMeta()
+	if err != nil {
+		return nil, err
+	}
+	return m.Collections, nil
+}
+
+// Collection returns the collection with the given ID.
+func (s *Store) Collection(id string) (Collection, error) {
+	m, err := s.readMeta()
+	if err != nil {
+		return Collection{}, err
+	}
+	for _, c := range m.Collections {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return Collection{}, ErrNotFound
+}
+
+func (m *metadata) collection(id string) *Collection {
+	for i := range m.Collections {
+		if m.Collections[i].ID == id {
+			return &m.Collections[i]
+		}
+	}
+	return nil
+}
+
+// CreateCollection adds a new, empty collection.
+func (s *Store) CreateCollection(name, description string) (Collection, error) {
+	name = strings.TrimSpace(name)
+	id := collectionID(name)
+	if id == "" {
+		return Collection{}, invalidf("collection name is empty")
+	}
+	c := Collection{
+		ID:          id,
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		Sessions:    []SessionRef{},
+		CreatedAt:   time.Now().UTC(),
+	}
+	err := s.updateMeta(func(m *metadata) error {
+		if m.collection(id) != nil {
+			return invalidf("collection %q already exists", name)
+		}
+		m.Collections = append(m.Collections, c)
+		return nil
+	})
+	if err != nil {
+		return Collection{}, err
+	}
+	return c, nil
+}
+
+// UpdateCollection changes a collection's description.
+func (s *Store) UpdateCollection(id, description string) (Collection, error) {
+	var out Collection
+	err := s.updateMeta(func(m *metadata) error {
+		c := m.collection(id)
+		if c == nil {
+			return ErrNotFound
+		}
+		c.Description = strings.TrimSpace(description)
+		out = *c
+		return nil
+	})
+	return out, err
+}
+
+// DeleteCollection removes a collection. The sessions themselves are untouched.
+func (s *Store) DeleteCollection(id string) error {
+	return s.updateMeta(func(m *metadata) error {
+		for i := range m.Collections {
+			if m.Collections[i].ID == id {
+				m.Collections = append(m.Collections[:i], m.Collections[i+1:]...)
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+}
+
+// AddToCollection appends a session to a collection if not already present.
+func (s *Store) AddToCollection(id string, ref SessionRef) error {
+	if !ref.valid() {
+		return invalidf("invalid session reference %q/%q", ref.Slug, ref.SessionID)
+	}
+	return s.updateMeta(func(m *metadata) error {
+		c := m.collection(id)
+		if c == nil {
+			return ErrNotFound
+		}
+		for _, r := range c.Sessions {
+			if r == ref {
+				return nil
+			}
+		}
+		c.Sessions = append(c.Sessions, ref)
+		return nil
+	})
+}
+
+// RemoveFromCollection removes a session from a collection.
+func (s *Store) RemoveFromCollection(id string, ref SessionRef) error {
+	return s.updateMeta(func(m *metadata) error {
+		c := m.collection(id)
+		if c == nil {
+			return ErrNotFound
+		}
+		for i, r := range c.Sessions {
+			if r == ref {
+				c.Sessions = append(c.Sessions[:i], c.Sessions[i+1:]...)
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStarsAndTags(t *testing.T) {
+	st, _ := Open(t.TempDir())
+	ref := SessionRef{Slug: "-proj", SessionID: "sess-1"}
+	opened := st.Modified()
+
+	if err := st.SetStarred(ref, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !st.Modified().After(opened) {
+		t.Errorf("Modified() = %v after a write, want later than %v", st.Modified(), opened)
+	}
+	tags, err := st.SetTags(ref, []string{" Refactoring ", "tdd", "refactoring", "prompt tips"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"prompt-tips", "refactoring", "tdd"}
+	if len(tags) != len(want) {
+		t.Fatalf("tags = %v, want %v", tags, want)
+	}
+	for i := range want {
+		if tags[i] != want[i] {
+			t.Errorf("tags[%d] = %q, want %q", i, tags[i], want[i])
+		}
+	}
+
+	meta, err := st.SessionMeta("sess-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !meta.Starred || meta.Slug != "-proj" {
+		t.Errorf("meta = %+v, want starred with slug -proj", meta)
+	}
+
+	tagged, _ := st.SessionsWithTag("TDD")
+	if len(tagged) != 1 || tagged[0].SessionID != "sess-1" {
+		t.Errorf("SessionsWithTag = %+v, want sess-1", tagged)
+	}
+
+	// Clearing all curation data removes the entry entirely.
+	st.SetStarred(ref, false)
+	st.SetTags(ref, nil)
+	all, _ := st.AllSessionMeta()
+	if len(all) != 0 {
+		t.Errorf("AllSessionMeta length = %d, want 0", len(all))
+	}
+}
+
+func TestTags(t *testing.T) {
+	st, _ := Open(t.TempDir())
+	st.SetTags(SessionRef{Slug: "-p", SessionID: "s1"}, []string{"a", "b"})
+	st.SetTags(SessionRef{Slug: "-p", SessionID: "s2"}, []string{"b"})
+
+	tags, err := st.Tags()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != (TagCount{Tag: "b", Count: 2}) {
+		t.Errorf("Tags = %+v, want b(2) first", tags)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	st, _ := Open(t.TempDir())
+
+	c, err := st.CreateCollection("Best Refactors!", "Sessions worth copying")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.ID != "best-refactors" {
+		t.Errorf("ID = %q, want %q", c.ID, "best-refactors")
+	}
+	if _, err := st.CreateCollection("best refactors", ""); err == nil {
+		t.Error("expected error for duplicate collection")
+	}
+
+	ref := SessionRef{Slug: "-proj", SessionID: "sess-1"}
+	if err := st.AddToCollection(c.ID, ref); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	st.AddToCollection(c.ID, ref) // duplicate is a no-op
+	if err := st.AddToCollection("missing", ref); !errors.Is(err, ErrNotFound) {
+		t.Errorf("AddToCollection missing: err = %v, want ErrNotFound", err)
+	}
+
+	got, err := st.Collection(c.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Sessions) != 1 {
+		t.Errorf("Sessions length = %d, want 1", len(got.Sessions))
+	}
+
+	if _, err := st.UpdateCollection(c.ID, "Updated"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := st.RemoveFromCollection(c.ID, ref); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := st.DeleteCollection(c.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	all, _ := st.Collections()
+	if len(all) != 0 {
+		t.Errorf("Collections length = %d, want 0", len(all))
+	}
+}
+
+func TestInvalidSessionRef(t *testing.T) {
+	st, _ := Open(t.TempDir())
+	c, err := st.CreateCollection("Refs", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []SessionRef{
+		{Slug: "", SessionID: "sess-1"},
+		{Slug: "..", SessionID: "sess-1"},
+		{Slug: "../../etc", SessionID: "sess-1"},
+		{Slug: "-proj", SessionID: "a/b"},
+	} {
+		if err := st.AddToCollection(c.ID, ref); !errors.Is(err, ErrInvalid) {
+			t.Errorf("AddToCollection(%+v) = %v, want ErrInvalid", ref, err)
+		}
+		if err := st.SetStarred(ref, true); !errors.Is(err, ErrInvalid) {
+			t.Errorf("SetStarred(%+v) = %v, want ErrInvalid", ref, err)
+		}
+		if _, err := st.SetTags(ref, []string{"x"}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("SetTags(%+v) = %v, want ErrInvalid", ref, err)
+		}
+	}
+	if all, _ := st.AllSessionMeta(); len(all) != 0 {
+		t.Errorf("AllSessionMeta = %+v, want nothing stored", all)
+	}
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotFound is returned when a requested record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrForbidden is returned when a caller tries to modify a record it does not own.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrInvalid is matched by errors reporting invalid input, such as an empty
+// comment body, as opposed to failures to read or write the data.
+var ErrInvalid = errors.New("invalid input")
+
+// invalidError is an ErrInvalid with its own message.
+type invalidError string
+
+func (e invalidError) Error() string        { return string(e) }
+func (e invalidError) Is(target error) bool { return target == ErrInvalid }
+
+func invalidf(format string, args ...any) error {
+	return invalidError(fmt.Sprintf(format, args...))
+}
+
+// Store persists user-generated data (comments, etc.) as JSON files under a
+// data directory. It is safe for concurrent use within a single process.
+type Store struct {
+	dir      string
+	mu       sync.Mutex
+	modified atomic.Int64 // Unix nanoseconds of the last write
+}
+
+// Open returns a Store rooted at dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	s := &Store{dir: dir}
+	s.modified.Store(time.Now().UnixNano())
+	return s, nil
+}
+
+// Dir returns the data directory backing the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Modified returns when the store was last written to, or opened if it
+// has not been. Changes made by other processes are not seen.
+func (s *Store) Modified() time.Time {
+	return time.Unix(0, s.modified.Load())
+}
+
+// readJSON decodes the file at path into v. A missing file leaves v untouched.
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// writeJSON atomically replaces the file at path with the JSON encoding of
+// v, and records the store as modified.
+func (s *Store) writeJSON(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", filepath.Base(path), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	s.modified.Store(time.Now().UnixNano())
+	return nil
+}
+
+// validKey reports whether k is safe to use as a file name component.
+func validKey(k string) bool {
+	return k != "" && k != "." && k != ".." && !strings.ContainsAny(k, `/\`)
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
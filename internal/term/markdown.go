@@ -0,0 +1,140 @@
+package term
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Markdown renders markdown source as styled terminal text. Line breaks in
+// the source are kept, since terminals do not reflow paragraphs.
+func Markdown(src string, st Styler) string {
+	source := []byte(src)
+	doc := goldmark.DefaultParser().Parse(text.NewReader(source))
+	r := mdRenderer{source: source, st: st}
+	return strings.Join(r.blocks(doc, false), "\n")
+}
+
+type mdRenderer struct {
+	source []byte
+	st     Styler
+}
+
+// blocks renders the block children of parent as lines, separated by blank
+// lines unless tight.
+func (r *mdRenderer) blocks(parent ast.Node, tight bool) []string {
+	var lines []string
+	for c := parent.FirstChild(); c != nil; c = c.NextSibling() {
+		if len(lines) > 0 && !tight {
+			lines = append(lines, "")
+		}
+		lines = append(lines, r.block(c)...)
+	}
+	return lines
+}
+
+func (r *mdRenderer) block(n ast.Node) []string {
+	switch n := n.(type) {
+	case *ast.Heading:
+		codes := []string{Bold}
+		if n.Level <= 2 {
+			codes = append(codes, Magenta)
+		}
+		return []string{r.st.Style(r.inline(n), codes...)}
+	case *ast.Paragraph, *ast.TextBlock:
+		return strings.Split(r.inline(n), "\n")
+	case *ast.ThematicBreak:
+		return []string{r.st.Style(strings.Repeat("─", 20), Faint)}
+	case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock:
+		var lines []string
+		segs := n.Lines()
+		for i := 0; i < segs.Len(); i++ {
+			seg := segs.At(i)
+			line := strings.TrimRight(string(seg.Value(r.source)), "\n")
+			if _, html := n.(*ast.HTMLBlock); !html {
+				line = "  " + r.st.Style(line, Cyan)
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	case *ast.Blockquote:
+		lines := r.blocks(n, false)
+		bar := r.st.Style("│", Faint) + " "
+		for i, l := range lines {
+			lines[i] = bar + l
+		}
+		return lines
+	case *ast.List:
+		var lines []string
+		num := n.Start
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			marker := "• "
+			if n.IsOrdered() {
+				marker = fmt.Sprintf("%d. ", num)
+				num++
+			}
+			if len(lines) > 0 && !n.IsTight {
+				lines = append(lines, "")
+			}
+			pad := strings.Repeat(" ", utf8.RuneCountInString(marker))
+			for i, l := range r.blocks(item, n.IsTight) {
+				switch {
+				case i == 0:
+					l = r.st.Style(marker, Yellow) + l
+				case l != "":
+					l = pad + l
+				}
+				lines = append(lines, l)
+			}
+		}
+		return lines
+	default:
+		return r.blocks(n, false)
+	}
+}
+
+// inline renders the inline children of n.
+func (r *mdRenderer) inline(n ast.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Value(r.source))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				b.WriteByte('\n')
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		case *ast.CodeSpan:
+			b.WriteString(r.st.Style(r.inline(c), Cyan))
+		case *ast.Emphasis:
+			code := Italic
+			if c.Level >= 2 {
+				code = Bold
+			}
+			b.WriteString(r.st.Style(r.inline(c), code))
+		case *ast.Link:
+			label, url := r.inline(c), string(c.Destination)
+			b.WriteString(r.st.Style(label, Underline))
+			if label != url {
+				b.WriteString(r.st.Style(" ("+url+")", Faint))
+			}
+		case *ast.AutoLink:
+			b.WriteString(r.st.Style(string(c.URL(r.source)), Underline))
+		case *ast.Image:
+			b.WriteString(r.st.Style("[image: "+r.inline(c)+"]", Faint))
+		case *ast.RawHTML:
+			for i := 0; i < c.Segments.Len(); i++ {
+				seg := c.Segments.At(i)
+				b.Write(seg.Value(r.source))
+			}
+		default:
+			b.WriteString(r.inline(c))
+		}
+	}
+	return b.String()
+}
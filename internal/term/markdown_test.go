@@ -0,0 +1,29 @@
+package term
+
+import "testing"
+
+func TestMarkdown(t *testing.T) {
+	src := "# Plan\n\nRun `go test` and **check**\nthe [docs](https://go.dev).\n\n- one\n- two\n  - nested\n\n1. first\n2. second\n\n> quoted\n\n```go\nfunc main() {}\n```\n"
+	want := "Plan\n\nRun go test and check\nthe docs (https://go.dev).\n\n• one\n• two\n  • nested\n\n1. first\n2. second\n\n│ quoted\n\n  func main() {}"
+	if got := Markdown(src, Styler{}); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMarkdown_Styled(t *testing.T) {
+	st := Styler{Enabled: true}
+	got := Markdown("Use `ls` **now**", st)
+	want := "Use \x1b[36mls\x1b[0m \x1b[1mnow\x1b[0m"
+	if got != want {
+		t.Errorf("Markdown() = %q, want %q", got, want)
+	}
+}
+
+func TestStyler(t *testing.T) {
+	if got := (Styler{}).Style("x", Bold); got != "x" {
+		t.Errorf("disabled Style = %q, want %q", got, "x")
+	}
+	if got := (Styler{Enabled: true}).Style("x", Bold, Red); got != "\x1b[1;31mx\x1b[0m" {
+		t.Errorf("Style = %q", got)
+	}
+}
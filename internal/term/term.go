@@ -0,0 +1,101 @@
+// Package term renders output for the terminal: ANSI styling, markdown and
+// paging.
+package term
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ANSI SGR codes used by Styler.
+const (
+	Bold      = "1"
+	Faint     = "2"
+	Italic    = "3"
+	Underline = "4"
+	Red       = "31"
+	Green     = "32"
+	Yellow    = "33"
+	Blue      = "34"
+	Magenta   = "35"
+	Cyan      = "36"
+)
+
+// Styler wraps text in ANSI escape sequences when enabled.
+type Styler struct {
+	Enabled bool
+}
+
+// Style returns s with the given SGR codes applied.
+func (st Styler) Style(s string, codes ...string) string {
+	if !st.Enabled || s == "" || len(codes) == 0 {
+		return s
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + s + "\x1b[0m"
+}
+
+// IsTerminal reports whether f is attached to a terminal.
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// ColorEnabled resolves a --color setting of "auto", "always" or "never".
+// Auto enables colour on terminals unless NO_COLOR is set.
+func ColorEnabled(mode string, f *os.File) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && IsTerminal(f)
+}
+
+// Pager is the output of a command, piped through $PAGER when writing to a
+// terminal.
+type Pager struct {
+	io.Writer
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// NewPager starts $PAGER (default "less -FRX") if enabled and stdout is a
+// terminal. Otherwise, or if the pager cannot be started, output goes
+// straight to stdout. Close must be called to wait for the pager to exit.
+func NewPager(enabled bool) *Pager {
+	p := &Pager{Writer: os.Stdout}
+	if !enabled || !IsTerminal(os.Stdout) {
+		return p
+	}
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less -FRX"
+	}
+	args := strings.Fields(pager)
+	if len(args) == 0 {
+		return p
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return p
+	}
+	if err := cmd.Start(); err != nil {
+		return p
+	}
+	p.Writer, p.cmd, p.stdin = stdin, cmd, stdin
+	return p
+}
+
+// Close flushes the output and waits for the pager to exit.
+func (p *Pager) Close() error {
+	if p.cmd == nil {
+		return nil
+	}
+	p.stdin.Close()
+	return p.cmd.Wait()
+}
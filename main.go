@@ -1,32 +1,32 @@
 package main
 
 import (
-	"flag"
 	"fmt"
-	"log/slog"
 	"net"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
-
-	"github.com/nhosoya/claude-code-share/internal/server"
 )
 
 func main() {
-	port := flag.Int("port", 3333, "HTTP server port")
-	host := flag.String("host", "0.0.0.0", "HTTP server host")
-	logDir := flag.String("log-dir", defaultLogDir(), "Path to Claude Code projects directory")
-	flag.Parse()
-
-	srv := server.New(*logDir)
-
-	addr := fmt.Sprintf("%s:%d", *host, *port)
-	printStartupInfo(addr, *port, *logDir)
-
-	slog.Info("starting server", "addr", addr, "log-dir", *logDir)
-	if err := http.ListenAndServe(addr, srv.Handler()); err != nil {
-		slog.Error("server error", "error", err)
+	// Without a command, start the server, so that flags alone keep working.
+	name, args := "serve", os.Args[1:]
+	switch {
+	case len(args) == 0:
+	case args[0] == "help" || args[0] == "-h" || args[0] == "--help":
+		printCommands(os.Stdout)
+		return
+	case !strings.HasPrefix(args[0], "-"):
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printCommands(os.Stderr)
+		os.Exit(2)
+	}
+	if err := cmd.run(args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
 		os.Exit(1)
 	}
 }
@@ -39,19 +39,31 @@ func defaultLogDir() string {
 	return filepath.Join(home, ".claude", "projects")
 }
 
+func defaultDataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "claude-code-share")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join("~", ".local", "share", "claude-code-share")
+	}
+	return filepath.Join(home, ".local", "share", "claude-code-share")
+}
+
 type lanAddr struct {
 	IP    string
 	Iface string
 }
 
-func printStartupInfo(addr string, port int, logDir string) {
+func printStartupInfo(addr string, port int, logDir, dataDir string) {
 	fmt.Printf("claude-code-share\n")
-	fmt.Printf("  Log directory: %s\n", logDir)
-	fmt.Printf("  Local:         http://localhost:%d\n", port)
+	fmt.Printf("  Log directory:  %s\n", logDir)
+	fmt.Printf("  Data directory: %s\n", dataDir)
+	fmt.Printf("  Local:          http://localhost:%d\n", port)
 
 	addrs := lanAddresses()
 	for _, a := range addrs {
-		fmt.Printf("  Network:       http://%s:%d (%s)\n", a.IP, port, a.Iface)
+		fmt.Printf("  Network:        http://%s:%d (%s)\n", a.IP, port, a.Iface)
 	}
 	fmt.Println()
 }
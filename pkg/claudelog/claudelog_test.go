@@ -0,0 +1,280 @@
+package claudelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testLog = `{"type":"user","uuid":"u1","parentUuid":null,"timestamp":"2026-02-25T06:41:55.945Z","sessionId":"s1","isSidechain":false,"entrypoint":"cli","message":{"role":"user","content":"Fix the bug"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2026-02-25T06:42:02.218Z","sessionId":"s1","requestId":"req_1","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-opus-4-6","content":[{"type":"thinking","thinking":"Look first","signature":"sig"},{"type":"text","text":"Reading it.","citations":null},{"type":"tool_use","id":"tu1","name":"Read","input":{"file_path":"/a.go"}}],"stop_reason":"tool_use","usage":{"input_tokens":100,"output_tokens":50,"cache_creation_input_tokens":0,"cache_read_input_tokens":10,"service_tier":"standard"}}}
+
+not json
+{"type":"user","uuid":"u2","parentUuid":"a1","timestamp":"2026-02-25T06:42:03Z","sessionId":"s1","toolUseResult":{"type":"text"},"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu1","content":[{"type":"text","text":"package a"}]},{"type":"server_thing","x":1}]}}
+`
+
+func TestReader(t *testing.T) {
+	r := NewReader(strings.NewReader(testLog))
+	var entries []*Entry
+	var syntax []*SyntaxError
+	for e, err := range r.All() {
+		var se *SyntaxError
+		switch {
+		case errors.As(err, &se):
+			syntax = append(syntax, se)
+		case err != nil:
+			t.Fatal(err)
+		default:
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if len(syntax) != 1 || syntax[0].Line != 4 || syntax[0].Offset != int64(strings.Index(testLog, "not json")) {
+		t.Errorf("syntax errors = %+v, want one at line 4", syntax)
+	}
+	if r.Offset() != int64(len(testLog)) {
+		t.Errorf("Offset() = %d, want %d", r.Offset(), len(testLog))
+	}
+	partial := NewReader(strings.NewReader(`{"type":"user"}` + "\n" + `{"type":`))
+	for range partial.All() {
+	}
+	if want := int64(len(`{"type":"user"}`+"\n"+`{"type":`) + 1); partial.Offset() != want {
+		t.Errorf("Offset() after a partial line = %d, want %d", partial.Offset(), want)
+	}
+
+	a := entries[1]
+	blocks := a.Message.Content.Blocks
+	if len(blocks) != 3 {
+		t.Fatalf("assistant has %d blocks, want 3", len(blocks))
+	}
+	if b, ok := blocks[0].(*ThinkingBlock); !ok || b.Thinking != "Look first" {
+		t.Errorf("block 0 = %#v, want thinking", blocks[0])
+	}
+	if b, ok := blocks[2].(*ToolUseBlock); !ok || b.Name != "Read" || string(b.Input) != `{"file_path":"/a.go"}` {
+		t.Errorf("block 2 = %#v, want Read tool use", blocks[2])
+	}
+	if a.Message.Usage.CacheReadInputTokens != 10 || string(a.Message.Usage.Extra["service_tier"]) != `"standard"` {
+		t.Errorf("usage = %+v", a.Message.Usage)
+	}
+	if string(entries[0].Extra["entrypoint"]) != `"cli"` {
+		t.Errorf("entry extra = %v, want entrypoint", entries[0].Extra)
+	}
+
+	result, ok := entries[2].Message.Content.Blocks[0].(*ToolResultBlock)
+	if !ok || result.ToolUseID != "tu1" || result.Content.Blocks[0].(*TextBlock).Text != "package a" {
+		t.Errorf("tool result = %#v", entries[2].Message.Content.Blocks[0])
+	}
+	if u, ok := entries[2].Message.Content.Blocks[1].(*UnknownBlock); !ok || u.Type != "server_thing" {
+		t.Errorf("unknown block = %#v", entries[2].Message.Content.Blocks[1])
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for i, line := range strings.Split(testLog, "\n") {
+		if line == "" || line == "not json" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatalf("line %d: marshal: %v", i+1, err)
+		}
+
+		// Every field survives, except those with zero values, which
+		// decode the same either way.
+		var want, got map[string]any
+		json.Unmarshal([]byte(line), &want)
+		json.Unmarshal(data, &got)
+		dropZero(want)
+		dropZero(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("line %d round trip:\n got %s\nwant %s", i+1, data, line)
+		}
+	}
+}
+
+// dropZero removes false, null and empty string values from JSON objects.
+func dropZero(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, x := range v {
+			if x == nil || x == false || x == "" {
+				delete(v, k)
+				continue
+			}
+			dropZero(x)
+		}
+	case []any:
+		for _, x := range v {
+			dropZero(x)
+		}
+	}
+}
+
+func TestContentErr(t *testing.T) {
+	var e Entry
+	line := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":5},{"type":"text","text":"ok"}]}}`
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.ContentErr() == nil {
+		t.Error("ContentErr = nil, want the bad text block")
+	}
+	blocks := e.Message.Content.Blocks
+	if _, ok := blocks[0].(*UnknownBlock); !ok {
+		t.Errorf("bad block = %T, want *UnknownBlock", blocks[0])
+	}
+	if b, ok := blocks[1].(*TextBlock); !ok || b.Text != "ok" {
+		t.Errorf("good block = %#v", blocks[1])
+	}
+	data, _ := json.Marshal(e.Message.Content)
+	if !bytes.Contains(data, []byte(`"text":5`)) {
+		t.Errorf("bad block not kept verbatim: %s", data)
+	}
+}
+
+func TestBlockExtra(t *testing.T) {
+	var c Content
+	if err := json.Unmarshal([]byte(`[{"type":"text","text":"a","citations":[]},{"type":"mcp_tool_use","id":"m1"}]`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if got := BlockExtra(c.Blocks[0]); len(got) != 1 || string(got["citations"]) != "[]" {
+		t.Errorf("BlockExtra(text) = %v, want citations", got)
+	}
+	if got := BlockExtra(c.Blocks[1]); got != nil {
+		t.Errorf("BlockExtra(unknown) = %v, want nil", got)
+	}
+}
+
+func TestEntryExtra(t *testing.T) {
+	var e Entry
+	line := `{"type":"user","Type":"x","foo":1,"message":{"role":"user","bar":true}}`
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != "user" {
+		t.Errorf("Type = %q, want %q", e.Type, "user")
+	}
+	if len(e.Extra) != 2 || string(e.Extra["Type"]) != `"x"` || string(e.Extra["foo"]) != "1" {
+		t.Errorf("Extra = %v, want Type and foo", e.Extra)
+	}
+	if len(e.Message.Extra) != 1 || string(e.Message.Extra["bar"]) != "true" {
+		t.Errorf("Message.Extra = %v, want bar", e.Message.Extra)
+	}
+}
+
+func TestServerBlocks(t *testing.T) {
+	data := `[
+		{"type":"document","title":"notes","source":{"type":"text","media_type":"text/plain","data":"hello"}},
+		{"type":"server_tool_use","id":"s1","name":"web_search","input":{"query":"go json"}},
+		{"type":"web_search_tool_result","tool_use_id":"s1","content":[{"type":"web_search_result","title":"Go","url":"https://go.dev","page_age":"1 day"}]},
+		{"type":"web_search_tool_result","tool_use_id":"s2","content":{"type":"web_search_tool_result_error","error_code":"max_uses_exceeded"}}
+	]`
+	var c Content
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Err() != nil {
+		t.Fatalf("Err() = %v", c.Err())
+	}
+	if len(c.Blocks) != 4 {
+		t.Fatalf("got %d blocks, want 4", len(c.Blocks))
+	}
+	if b, ok := c.Blocks[0].(*DocumentBlock); !ok || b.Title != "notes" || b.Source.Data != "hello" {
+		t.Errorf("block 0 = %#v, want document", c.Blocks[0])
+	}
+	if b, ok := c.Blocks[1].(*ServerToolUseBlock); !ok || b.Name != "web_search" || string(b.Input) != `{"query":"go json"}` {
+		t.Errorf("block 1 = %#v, want server tool use", c.Blocks[1])
+	}
+	if b, ok := c.Blocks[2].(*WebSearchToolResultBlock); !ok || len(b.Content.Results) != 1 || b.Content.Results[0].URL != "https://go.dev" {
+		t.Errorf("block 2 = %#v, want search results", c.Blocks[2])
+	}
+	if b, ok := c.Blocks[3].(*WebSearchToolResultBlock); !ok || b.Content.ErrorCode != "max_uses_exceeded" || b.Content.Results != nil {
+		t.Errorf("block 3 = %#v, want search error", c.Blocks[3])
+	}
+
+	out, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want any
+	json.Unmarshal(out, &got)
+	json.Unmarshal([]byte(data), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip:\n got %s\nwant %s", out, data)
+	}
+}
+
+func TestWalker(t *testing.T) {
+	root := t.TempDir()
+	for _, p := range []string{"-b/s2.jsonl", "-b/s1.jsonl", "-a/s3.jsonl", "-a/notes.txt", "-empty/x.txt"} {
+		path := filepath.Join(root, p)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(testLog), 0644)
+	}
+
+	w := Walker{Root: root}
+	var got []string
+	err := w.Walk(func(f SessionFile) error {
+		got = append(got, f.Project+"/"+f.ID)
+		if f.Size != int64(len(testLog)) {
+			t.Errorf("%s size = %d", f.Path, f.Size)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"-a/s3", "-b/s1", "-b/s2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("walked %v, want %v", got, want)
+	}
+
+	got = nil
+	w.Walk(func(f SessionFile) error {
+		got = append(got, f.ID)
+		return SkipProject
+	})
+	if want := []string{"s3", "s1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("walked with SkipProject %v, want %v", got, want)
+	}
+
+	f, err := w.Session("-b", "s2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := f.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if e, err := r.Next(); err != nil || e.UUID != "u1" {
+		t.Errorf("first entry = %v, %v", e, err)
+	}
+	if _, err := w.Session("..", "s2"); err == nil {
+		t.Error("Session(..) succeeded")
+	}
+}
+
+func TestReadFileFailsOnSyntaxError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	os.WriteFile(path, []byte(testLog), 0644)
+	_, err := ReadFile(path)
+	var se *SyntaxError
+	if !errors.As(err, &se) || se.Line != 4 {
+		t.Errorf("ReadFile error = %v, want a syntax error at line 4", err)
+	}
+	if _, err := NewReader(strings.NewReader("")).Next(); err != io.EOF {
+		t.Errorf("Next on empty input = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,386 @@
+package claudelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Content is the content of a message: either plain text or a list of
+// blocks.
+type Content struct {
+	Text   string  // When the content is a string
+	Blocks []Block // When the content is a list; nil otherwise
+	// IsBlocks reports whether the content is a list, which may be empty.
+	IsBlocks bool
+
+	err error
+}
+
+// Err returns the first error decoding a block, including the blocks of
+// tool results. Blocks that could not be decoded are kept as *UnknownBlock
+// so the rest of the content is still usable.
+func (c Content) Err() error {
+	return c.err
+}
+
+// UnmarshalJSON decodes a string or a list of blocks. It only fails if the
+// content is neither; see Err for blocks that could not be decoded.
+func (c *Content) UnmarshalJSON(data []byte) error {
+	*c = Content{}
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		return json.Unmarshal(data, &c.Text)
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return fmt.Errorf("content is neither a string nor a list: %w", err)
+	}
+	c.IsBlocks = true
+	c.Blocks = make([]Block, 0, len(raws))
+	for i, raw := range raws {
+		b, err := decodeBlock(raw)
+		if err != nil && c.err == nil {
+			c.err = fmt.Errorf("block %d: %w", i, err)
+		}
+		c.Blocks = append(c.Blocks, b)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the content as a string, or as a list if IsBlocks is
+// set or there are blocks.
+func (c Content) MarshalJSON() ([]byte, error) {
+	if !c.IsBlocks && c.Blocks == nil {
+		return json.Marshal(c.Text)
+	}
+	if c.Blocks == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(c.Blocks)
+}
+
+// Block is a content block. It is one of *TextBlock, *ThinkingBlock,
+// *RedactedThinkingBlock, *ToolUseBlock, *ToolResultBlock, *ImageBlock,
+// *DocumentBlock, *ServerToolUseBlock, *WebSearchToolResultBlock or
+// *UnknownBlock.
+type Block interface {
+	// BlockType returns the block's "type" field, e.g. "text".
+	BlockType() string
+	json.Marshaler
+	extra() *Extra
+}
+
+// Block types.
+const (
+	TypeText                = "text"
+	TypeThinking            = "thinking"
+	TypeRedactedThinking    = "redacted_thinking"
+	TypeToolUse             = "tool_use"
+	TypeToolResult          = "tool_result"
+	TypeImage               = "image"
+	TypeDocument            = "document"
+	TypeServerToolUse       = "server_tool_use"
+	TypeWebSearchToolResult = "web_search_tool_result"
+)
+
+// TextBlock is text written by the user or the assistant.
+type TextBlock struct {
+	Text  string `json:"text"`
+	Extra Extra  `json:"-"`
+}
+
+// ThinkingBlock is the assistant's extended thinking.
+type ThinkingBlock struct {
+	Thinking  string `json:"thinking"`
+	Signature string `json:"signature,omitempty"`
+	Extra     Extra  `json:"-"`
+}
+
+// RedactedThinkingBlock is extended thinking that was encrypted by the API.
+type RedactedThinkingBlock struct {
+	Data  string `json:"data"`
+	Extra Extra  `json:"-"`
+}
+
+// ToolUseBlock is a tool call made by the assistant. Input is the tool's
+// arguments as a JSON object.
+type ToolUseBlock struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+	Extra Extra           `json:"-"`
+}
+
+// ToolResultBlock is the output of a tool call, sent back in a user
+// message.
+type ToolResultBlock struct {
+	ToolUseID string  `json:"tool_use_id"`
+	Content   Content `json:"content"`
+	IsError   bool    `json:"is_error,omitempty"`
+	Extra     Extra   `json:"-"`
+}
+
+// ImageBlock is an image, usually base64 encoded in Source.
+type ImageBlock struct {
+	Source ImageSource `json:"source"`
+	Extra  Extra       `json:"-"`
+}
+
+// ImageSource is where an image's data comes from.
+type ImageSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// DocumentBlock is a document attached to a user message, such as a PDF.
+type DocumentBlock struct {
+	Source DocumentSource `json:"source"`
+	Title  string         `json:"title,omitempty"`
+	Extra  Extra          `json:"-"`
+}
+
+// DocumentSource is where a document's data comes from: base64 Data of
+// MediaType, a URL, or for plain text documents the text itself in Data.
+type DocumentSource struct {
+	Type      string `json:"type"` // "base64", "url" or "text"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// ServerToolUseBlock is a call to a tool run by the API rather than by
+// Claude Code, such as web search. Input is the tool's arguments as a JSON
+// object.
+type ServerToolUseBlock struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+	Extra Extra           `json:"-"`
+}
+
+// WebSearchToolResultBlock is the outcome of a web search server tool
+// call.
+type WebSearchToolResultBlock struct {
+	ToolUseID string           `json:"tool_use_id"`
+	Content   WebSearchContent `json:"content"`
+	Extra     Extra            `json:"-"`
+}
+
+// WebSearchContent is either the pages a web search found or, if it
+// failed, an error code such as "max_uses_exceeded".
+type WebSearchContent struct {
+	Results   []WebSearchResult
+	ErrorCode string
+}
+
+// WebSearchResult is a page found by a web search.
+type WebSearchResult struct {
+	Type             string `json:"type"` // "web_search_result"
+	Title            string `json:"title"`
+	URL              string `json:"url"`
+	PageAge          string `json:"page_age,omitempty"`
+	EncryptedContent string `json:"encrypted_content,omitempty"`
+}
+
+// webSearchError is the content of a failed web search.
+type webSearchError struct {
+	Type      string `json:"type"` // "web_search_tool_result_error"
+	ErrorCode string `json:"error_code"`
+}
+
+// UnmarshalJSON decodes a list of results or an error.
+func (c *WebSearchContent) UnmarshalJSON(data []byte) error {
+	*c = WebSearchContent{}
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, &c.Results)
+	}
+	var e webSearchError
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	c.ErrorCode = e.ErrorCode
+	return nil
+}
+
+// MarshalJSON encodes the error if ErrorCode is set, and the results
+// otherwise.
+func (c WebSearchContent) MarshalJSON() ([]byte, error) {
+	if c.ErrorCode != "" {
+		return json.Marshal(webSearchError{Type: "web_search_tool_result_error", ErrorCode: c.ErrorCode})
+	}
+	if c.Results == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(c.Results)
+}
+
+// UnknownBlock is a block of a type this package does not model, or one
+// that could not be decoded. Raw is the block's JSON.
+type UnknownBlock struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+func (*TextBlock) BlockType() string                { return TypeText }
+func (*ThinkingBlock) BlockType() string            { return TypeThinking }
+func (*RedactedThinkingBlock) BlockType() string    { return TypeRedactedThinking }
+func (*ToolUseBlock) BlockType() string             { return TypeToolUse }
+func (*ToolResultBlock) BlockType() string          { return TypeToolResult }
+func (*ImageBlock) BlockType() string               { return TypeImage }
+func (*DocumentBlock) BlockType() string            { return TypeDocument }
+func (*ServerToolUseBlock) BlockType() string       { return TypeServerToolUse }
+func (*WebSearchToolResultBlock) BlockType() string { return TypeWebSearchToolResult }
+func (b *UnknownBlock) BlockType() string           { return b.Type }
+
+func (b *TextBlock) extra() *Extra                { return &b.Extra }
+func (b *ThinkingBlock) extra() *Extra            { return &b.Extra }
+func (b *RedactedThinkingBlock) extra() *Extra    { return &b.Extra }
+func (b *ToolUseBlock) extra() *Extra             { return &b.Extra }
+func (b *ToolResultBlock) extra() *Extra          { return &b.Extra }
+func (b *ImageBlock) extra() *Extra               { return &b.Extra }
+func (b *DocumentBlock) extra() *Extra            { return &b.Extra }
+func (b *ServerToolUseBlock) extra() *Extra       { return &b.Extra }
+func (b *WebSearchToolResultBlock) extra() *Extra { return &b.Extra }
+func (b *UnknownBlock) extra() *Extra             { return nil }
+
+// BlockExtra returns the fields of b that this package does not model. It
+// is nil for an UnknownBlock, whose fields are all in Raw.
+func BlockExtra(b Block) Extra {
+	if e := b.extra(); e != nil {
+		return *e
+	}
+	return nil
+}
+
+// The aliases drop the MarshalJSON methods so encodeExtra can marshal the
+// fields.
+type (
+	textFields                TextBlock
+	thinkingFields            ThinkingBlock
+	redactedThinkingFields    RedactedThinkingBlock
+	toolUseFields             ToolUseBlock
+	toolResultFields          ToolResultBlock
+	imageFields               ImageBlock
+	documentFields            DocumentBlock
+	serverToolUseFields       ServerToolUseBlock
+	webSearchToolResultFields WebSearchToolResultBlock
+)
+
+func (b *TextBlock) MarshalJSON() ([]byte, error) {
+	return encodeExtra((*textFields)(b), b.Extra, TypeText)
+}
+
+func (b *ThinkingBlock) MarshalJSON() ([]byte, error) {
+	return encodeExtra((*thinkingFields)(b), b.Extra, TypeThinking)
+}
+
+func (b *RedactedThinkingBlock) MarshalJSON() ([]byte, error) {
+	return encodeExtra((*redactedThinkingFields)(b), b.Extra, TypeRedactedThinking)
+}
+
+func (b *ToolUseBlock) MarshalJSON() ([]byte, error) {
+	if b.Input == nil {
+		c := *b
+		c.Input = json.RawMessage("{}")
+		b = &c
+	}
+	return encodeExtra((*toolUseFields)(b), b.Extra, TypeToolUse)
+}
+
+func (b *ToolResultBlock) MarshalJSON() ([]byte, error) {
+	return encodeExtra((*toolResultFields)(b), b.Extra, TypeToolResult)
+}
+
+func (b *ImageBlock) MarshalJSON() ([]byte, error) {
+	return encodeExtra((*imageFields)(b), b.Extra, TypeImage)
+}
+
+func (b *DocumentBlock) MarshalJSON() ([]byte, error) {
+	return encodeExtra((*documentFields)(b), b.Extra, TypeDocument)
+}
+
+func (b *ServerToolUseBlock) MarshalJSON() ([]byte, error) {
+	if b.Input == nil {
+		c := *b
+		c.Input = json.RawMessage("{}")
+		b = &c
+	}
+	return encodeExtra((*serverToolUseFields)(b), b.Extra, TypeServerToolUse)
+}
+
+func (b *WebSearchToolResultBlock) MarshalJSON() ([]byte, error) {
+	return encodeExtra((*webSearchToolResultFields)(b), b.Extra, TypeWebSearchToolResult)
+}
+
+func (b *UnknownBlock) MarshalJSON() ([]byte, error) {
+	if b.Raw == nil {
+		return encodeExtra(struct{}{}, nil, b.Type)
+	}
+	return b.Raw, nil
+}
+
+// decodeBlock decodes a block by its type. Blocks of unknown types, and
+// blocks that fail to decode, are returned as *UnknownBlock, the latter
+// together with the error.
+func decodeBlock(raw json.RawMessage) (Block, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return &UnknownBlock{Raw: raw}, err
+	}
+	var typ string
+	if t, ok := obj["type"]; ok {
+		if err := json.Unmarshal(t, &typ); err != nil {
+			return &UnknownBlock{Raw: raw}, err
+		}
+	}
+
+	var b Block
+	var fields any
+	switch typ {
+	case TypeText:
+		t := new(TextBlock)
+		b, fields = t, (*textFields)(t)
+	case TypeThinking:
+		t := new(ThinkingBlock)
+		b, fields = t, (*thinkingFields)(t)
+	case TypeRedactedThinking:
+		t := new(RedactedThinkingBlock)
+		b, fields = t, (*redactedThinkingFields)(t)
+	case TypeToolUse:
+		t := new(ToolUseBlock)
+		b, fields = t, (*toolUseFields)(t)
+	case TypeToolResult:
+		t := new(ToolResultBlock)
+		b, fields = t, (*toolResultFields)(t)
+	case TypeImage:
+		t := new(ImageBlock)
+		b, fields = t, (*imageFields)(t)
+	case TypeDocument:
+		t := new(DocumentBlock)
+		b, fields = t, (*documentFields)(t)
+	case TypeServerToolUse:
+		t := new(ServerToolUseBlock)
+		b, fields = t, (*serverToolUseFields)(t)
+	case TypeWebSearchToolResult:
+		t := new(WebSearchToolResultBlock)
+		b, fields = t, (*webSearchToolResultFields)(t)
+	default:
+		return &UnknownBlock{Type: typ, Raw: raw}, nil
+	}
+
+	extra, err := decodeFields(obj, fields, "type")
+	if err != nil {
+		return &UnknownBlock{Type: typ, Raw: raw}, fmt.Errorf("%s: %w", typ, err)
+	}
+	*b.extra() = extra
+	if t, ok := b.(*ToolResultBlock); ok && t.Content.err != nil {
+		return b, t.Content.err
+	}
+	return b, nil
+}
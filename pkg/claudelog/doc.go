@@ -0,0 +1,36 @@
+// Package claudelog reads and writes the session logs Claude Code keeps
+// under ~/.claude/projects.
+//
+// Each project directory holds one JSONL file per session, with one Entry
+// per line. A Reader decodes the entries of a file, and a Walker finds the
+// session files of every project:
+//
+//	w := claudelog.Walker{Root: claudelog.DefaultRoot()}
+//	err := w.Walk(func(f claudelog.SessionFile) error {
+//		r, err := f.Open()
+//		if err != nil {
+//			return err
+//		}
+//		defer r.Close()
+//		for e, err := range r.All() {
+//			...
+//		}
+//		return nil
+//	})
+//
+// Message content is either a string or a list of blocks. Blocks are one of
+// the Block types in this package, so a type switch covers every case; the
+// block types this package does not know decode to *UnknownBlock.
+//
+// The log format is not documented by Anthropic and changes between Claude
+// Code versions. Fields this package does not model are kept in the Extra
+// field of their struct, so that marshalling a decoded Entry produces JSON
+// that decodes to an equal Entry and loses no data.
+//
+// # Compatibility
+//
+// The package follows the module's semantic version: within a major
+// version, exported identifiers are not removed or changed incompatibly.
+// New fields and Block types may be added as Claude Code's format evolves,
+// so type switches over Block should have a default case.
+package claudelog
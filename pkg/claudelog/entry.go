@@ -0,0 +1,146 @@
+package claudelog
+
+import (
+	"time"
+)
+
+// Entry types.
+const (
+	EntryUser                = "user"
+	EntryAssistant           = "assistant"
+	EntrySystem              = "system"
+	EntrySummary             = "summary"
+	EntryFileHistorySnapshot = "file-history-snapshot"
+	EntryProgress            = "progress"
+)
+
+// Entry is one line of a session log. Which fields are set depends on Type:
+// user and assistant entries carry a Message, system entries a Subtype,
+// and file-history-snapshot entries a Snapshot.
+type Entry struct {
+	Type       string    `json:"type"`
+	UUID       string    `json:"uuid,omitempty"`
+	ParentUUID *string   `json:"parentUuid,omitempty"` // Nil for the first entry of a thread
+	Timestamp  time.Time `json:"timestamp,omitzero"`
+	SessionID  string    `json:"sessionId,omitempty"`
+	Version    string    `json:"version,omitempty"` // Claude Code version
+	CWD        string    `json:"cwd,omitempty"`
+	GitBranch  string    `json:"gitBranch,omitempty"`
+	UserType   string    `json:"userType,omitempty"`
+	RequestID  string    `json:"requestId,omitempty"` // API request of an assistant entry
+	// IsSidechain marks entries of a subagent conversation.
+	IsSidechain bool     `json:"isSidechain,omitempty"`
+	Message     *Message `json:"message,omitempty"`
+
+	// For system entries, e.g. the compact_boundary Claude Code writes
+	// when it compacts the context, and the summary message that follows.
+	Subtype          string           `json:"subtype,omitempty"`
+	CompactMetadata  *CompactMetadata `json:"compactMetadata,omitempty"`
+	IsCompactSummary bool             `json:"isCompactSummary,omitempty"`
+
+	// For file-history-snapshot entries.
+	MessageID        string        `json:"messageId,omitempty"`
+	Snapshot         *FileSnapshot `json:"snapshot,omitempty"`
+	IsSnapshotUpdate bool          `json:"isSnapshotUpdate,omitempty"`
+
+	Extra Extra `json:"-"`
+}
+
+// CompactMetadata describes a context compaction.
+type CompactMetadata struct {
+	Trigger   string `json:"trigger"`   // "auto" or "manual"
+	PreTokens int    `json:"preTokens"` // Context size before compaction
+}
+
+// FileSnapshot is the checkpoint Claude Code records before a user
+// message, listing the backups of every file it has modified so far.
+type FileSnapshot struct {
+	MessageID          string                `json:"messageId"`
+	TrackedFileBackups map[string]FileBackup `json:"trackedFileBackups"`
+	Timestamp          time.Time             `json:"timestamp"`
+}
+
+// FileBackup refers to a copy of a file's contents saved under
+// ~/.claude/file-history/<sessionId>/. BackupFileName is empty when the
+// file did not exist at the time of the backup.
+type FileBackup struct {
+	BackupFileName string    `json:"backupFileName"`
+	Version        int       `json:"version"`
+	BackupTime     time.Time `json:"backupTime"`
+}
+
+// Message is the API message of a user or assistant entry.
+type Message struct {
+	ID         string  `json:"id,omitempty"` // API message ID of assistant messages
+	Role       string  `json:"role"`
+	Model      string  `json:"model,omitempty"`
+	Content    Content `json:"content"`
+	StopReason string  `json:"stop_reason,omitempty"`
+	Usage      *Usage  `json:"usage,omitempty"`
+	Extra      Extra   `json:"-"`
+}
+
+// Usage is the token usage of an API call.
+type Usage struct {
+	InputTokens              int   `json:"input_tokens"`
+	OutputTokens             int   `json:"output_tokens"`
+	CacheCreationInputTokens int   `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int   `json:"cache_read_input_tokens"`
+	Extra                    Extra `json:"-"`
+}
+
+// ContextTokens returns the size of the prompt the call was made with:
+// fresh input plus cached input read or written.
+func (u Usage) ContextTokens() int {
+	return u.InputTokens + u.CacheReadInputTokens + u.CacheCreationInputTokens
+}
+
+// The aliases drop the JSON methods so decodeExtra and encodeExtra can
+// handle the fields.
+type (
+	entryFields   Entry
+	messageFields Message
+	usageFields   Usage
+)
+
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	*e = Entry{}
+	extra, err := decodeExtra(data, (*entryFields)(e))
+	e.Extra = extra
+	return err
+}
+
+func (e Entry) MarshalJSON() ([]byte, error) {
+	return encodeExtra((*entryFields)(&e), e.Extra, "")
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	*m = Message{}
+	extra, err := decodeExtra(data, (*messageFields)(m))
+	m.Extra = extra
+	return err
+}
+
+func (m Message) MarshalJSON() ([]byte, error) {
+	return encodeExtra((*messageFields)(&m), m.Extra, "")
+}
+
+func (u *Usage) UnmarshalJSON(data []byte) error {
+	*u = Usage{}
+	extra, err := decodeExtra(data, (*usageFields)(u))
+	u.Extra = extra
+	return err
+}
+
+func (u Usage) MarshalJSON() ([]byte, error) {
+	return encodeExtra((*usageFields)(&u), u.Extra, "")
+}
+
+// ContentErr returns the error decoding the entry's content blocks, if any.
+// See Content.Err.
+func (e *Entry) ContentErr() error {
+	if e.Message == nil {
+		return nil
+	}
+	return e.Message.Content.Err()
+}
@@ -0,0 +1,37 @@
+package claudelog_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nhosoya/claude-code-share/pkg/claudelog"
+)
+
+func ExampleReader_All() {
+	log := `{"type":"user","uuid":"u1","message":{"role":"user","content":"List the files"}}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"Sure."},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"ls"}}]}}
+`
+	r := claudelog.NewReader(strings.NewReader(log))
+	for e, err := range r.All() {
+		if err != nil {
+			fmt.Println("skipping:", err)
+			continue
+		}
+		if !e.Message.Content.IsBlocks {
+			fmt.Printf("%s: %s\n", e.Type, e.Message.Content.Text)
+			continue
+		}
+		for _, b := range e.Message.Content.Blocks {
+			switch b := b.(type) {
+			case *claudelog.TextBlock:
+				fmt.Printf("%s: %s\n", e.Type, b.Text)
+			case *claudelog.ToolUseBlock:
+				fmt.Printf("%s calls %s %s\n", e.Type, b.Name, b.Input)
+			}
+		}
+	}
+	// Output:
+	// user: List the files
+	// assistant: Sure.
+	// assistant calls Bash {"command":"ls"}
+}
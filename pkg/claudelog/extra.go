@@ -0,0 +1,103 @@
+package claudelog
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Extra holds the JSON fields of an object that this package does not
+// model, keyed by name, so that they survive a round trip.
+type Extra map[string]json.RawMessage
+
+// structField is a field of a struct decoded by decodeExtra.
+type structField struct {
+	name  string // JSON name
+	index int
+}
+
+// structFields caches the JSON fields declared by struct types.
+var structFields sync.Map // reflect.Type -> []structField
+
+func jsonFields(t reflect.Type) []structField {
+	if v, ok := structFields.Load(t); ok {
+		return v.([]structField)
+	}
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" {
+			name = f.Name
+		}
+		if f.IsExported() && name != "-" {
+			fields = append(fields, structField{name, i})
+		}
+	}
+	structFields.Store(t, fields)
+	return fields
+}
+
+// decodeExtra decodes data, a JSON object, into v, a pointer to a struct
+// without an UnmarshalJSON method, and returns the fields the struct does
+// not declare other than skip. The object is split into its fields once,
+// and each field is decoded from its own bytes. Unlike json.Unmarshal,
+// names must match the struct's exactly.
+func decodeExtra(data []byte, v any, skip ...string) (Extra, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return decodeFields(raw, v, skip...)
+}
+
+// decodeFields is decodeExtra for an object already split into fields. It
+// takes ownership of raw. Like json.Unmarshal, it decodes every field it
+// can and returns the first error.
+func decodeFields(raw map[string]json.RawMessage, v any, skip ...string) (Extra, error) {
+	rv := reflect.ValueOf(v).Elem()
+	var firstErr error
+	for _, f := range jsonFields(rv.Type()) {
+		data, ok := raw[f.name]
+		if !ok {
+			continue
+		}
+		delete(raw, f.name)
+		if err := json.Unmarshal(data, rv.Field(f.index).Addr().Interface()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, k := range skip {
+		delete(raw, k)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return raw, nil
+}
+
+// encodeExtra marshals v, a struct without a MarshalJSON method, adding the
+// extra fields and, if typ is not empty, a "type" field.
+func encodeExtra(v any, extra Extra, typ string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 && typ == "" {
+		return data, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for k, raw := range extra {
+		if _, ok := m[k]; !ok {
+			m[k] = raw
+		}
+	}
+	if typ != "" {
+		m["type"], _ = json.Marshal(typ)
+	}
+	return json.Marshal(m)
+}
@@ -0,0 +1,149 @@
+package claudelog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+)
+
+// MaxLineSize is the longest line a Reader accepts. Lines holding large
+// tool results or images can run to megabytes.
+const MaxLineSize = 64 << 20
+
+// SyntaxError reports a line that is not a valid entry. Reading can
+// continue after it.
+type SyntaxError struct {
+	Line   int   // 1-based line number
+	Offset int64 // Byte offset of the line
+	Err    error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads the entries of a session log.
+type Reader struct {
+	sc     *bufio.Scanner
+	closer io.Closer
+	line   int
+	offset int64 // Of the current line
+	next   int64 // Of the line after it
+}
+
+// NewReader returns a Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), MaxLineSize)
+	return &Reader{sc: sc}
+}
+
+// Open opens the session log at path. The Reader must be closed.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := NewReader(f)
+	r.closer = f
+	return r, nil
+}
+
+// Close closes the file opened by Open. It does nothing for a Reader made
+// by NewReader.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// Next returns the next entry, skipping blank lines, or io.EOF at the end.
+// A line that is not a valid entry yields a *SyntaxError, after which Next
+// can be called again. Any other error is fatal.
+func (r *Reader) Next() (*Entry, error) {
+	for r.sc.Scan() {
+		r.line++
+		r.offset = r.next
+		line := r.sc.Bytes()
+		r.next += int64(len(line)) + 1
+		if len(line) == 0 {
+			continue
+		}
+		e := new(Entry)
+		if err := json.Unmarshal(line, e); err != nil {
+			return nil, &SyntaxError{Line: r.line, Offset: r.offset, Err: err}
+		}
+		return e, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", r.line+1, err)
+	}
+	return nil, io.EOF
+}
+
+// Line returns the raw JSON of the entry last returned by Next. It is only
+// valid until the next call.
+func (r *Reader) Line() []byte {
+	return r.sc.Bytes()
+}
+
+// Pos returns the 1-based line number and byte offset of the entry last
+// returned by Next.
+func (r *Reader) Pos() (line int, offset int64) {
+	return r.line, r.offset
+}
+
+// Offset returns the number of bytes read up to the end of the last line,
+// counting its newline. It exceeds the bytes actually read when the input
+// ends without a newline, as when the last line is still being written.
+func (r *Reader) Offset() int64 {
+	return r.next
+}
+
+// All returns an iterator over the remaining entries. Lines that are not
+// valid entries are yielded as a nil entry and a *SyntaxError, and
+// iteration continues; it stops after any other error.
+func (r *Reader) All() iter.Seq2[*Entry, error] {
+	return func(yield func(*Entry, error) bool) {
+		for {
+			e, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(e, err) {
+				return
+			}
+			var syntax *SyntaxError
+			if err != nil && !errors.As(err, &syntax) {
+				return
+			}
+		}
+	}
+}
+
+// ReadFile reads every entry of the session log at path, failing on the
+// first line that is not a valid entry.
+func ReadFile(path string) ([]*Entry, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var entries []*Entry
+	for e, err := range r.All() {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -0,0 +1,142 @@
+package claudelog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultRoot returns ~/.claude/projects, where Claude Code keeps its
+// session logs.
+func DefaultRoot() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join("~", ".claude", "projects")
+	}
+	return filepath.Join(home, ".claude", "projects")
+}
+
+// SessionFile is the log of one session.
+type SessionFile struct {
+	Project string // Name of the project directory, e.g. "-Users-me-app"
+	ID      string // Session ID, the file name without ".jsonl"
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Open opens the session log for reading.
+func (f SessionFile) Open() (*Reader, error) {
+	return Open(f.Path)
+}
+
+// SkipProject can be returned by a WalkFunc to skip the remaining sessions
+// of the current project.
+var SkipProject = errors.New("skip this project")
+
+// WalkFunc is called by Walker.Walk for each session file. Returning
+// SkipProject skips the rest of the project; any other error stops the
+// walk and is returned by Walk.
+type WalkFunc func(f SessionFile) error
+
+// Walker finds the session logs under a projects directory, which has a
+// subdirectory per project holding a <session-id>.jsonl file per session.
+type Walker struct {
+	Root string
+}
+
+// Projects returns the names of the project directories that hold at least
+// one session, sorted.
+func (w Walker) Projects() ([]string, error) {
+	entries, err := os.ReadDir(w.Root)
+	if err != nil {
+		return nil, fmt.Errorf("read projects dir: %w", err)
+	}
+	var projects []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if files, _ := filepath.Glob(filepath.Join(w.Root, e.Name(), "*.jsonl")); len(files) > 0 {
+			projects = append(projects, e.Name())
+		}
+	}
+	return projects, nil
+}
+
+// Sessions returns the session files of a project, sorted by ID. Files
+// that disappear while listing are left out.
+func (w Walker) Sessions(project string) ([]SessionFile, error) {
+	if !validName(project) {
+		return nil, fmt.Errorf("invalid project name %q", project)
+	}
+	paths, err := filepath.Glob(filepath.Join(w.Root, project, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	files := make([]SessionFile, 0, len(paths))
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, SessionFile{
+			Project: project,
+			ID:      strings.TrimSuffix(filepath.Base(p), ".jsonl"),
+			Path:    p,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+// Session returns the file of one session.
+func (w Walker) Session(project, id string) (SessionFile, error) {
+	if !validName(project) || !validName(id) {
+		return SessionFile{}, fmt.Errorf("invalid session %q/%q", project, id)
+	}
+	p := filepath.Join(w.Root, project, id+".jsonl")
+	info, err := os.Stat(p)
+	if err != nil {
+		return SessionFile{}, err
+	}
+	return SessionFile{Project: project, ID: id, Path: p, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// validName reports whether name is a single path element.
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// Walk calls fn for every session file, project by project in name order.
+func (w Walker) Walk(fn WalkFunc) error {
+	projects, err := w.Projects()
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		files, err := w.Sessions(p)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := fn(f); err != nil {
+				if errors.Is(err, SkipProject) {
+					break
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
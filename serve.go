@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/server"
+	"github.com/nhosoya/claude-code-share/internal/store"
+)
+
+// setupServe defines the serve command, which starts the web server.
+func setupServe(fs *flag.FlagSet) func(args []string) error {
+	port := fs.Int("port", 3333, "HTTP server port")
+	host := fs.String("host", "0.0.0.0", "HTTP server host")
+	logDir := fs.String("log-dir", defaultLogDir(), "Path to Claude Code projects directory")
+	dataDir := fs.String("data-dir", defaultDataDir(), "Path to store comments and other shared data")
+	timezone := fs.String("timezone", "", "IANA time zone to display times in (default: system local)")
+	readHeaderTimeout := fs.Duration("read-header-timeout", 10*time.Second, "Maximum time to read request headers")
+	readTimeout := fs.Duration("read-timeout", 30*time.Second, "Maximum time to read a request, including its body")
+	writeTimeout := fs.Duration("write-timeout", 2*time.Minute, "Maximum time to write a response (0 means no limit)")
+	idleTimeout := fs.Duration("idle-timeout", 2*time.Minute, "How long to keep idle keep-alive connections open")
+	maxHeaderBytes := fs.Int("max-header-bytes", http.DefaultMaxHeaderBytes, "Maximum size of request headers in bytes")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 15*time.Second, "How long to wait for in-flight requests on shutdown")
+	cacheSize := fs.Int64("cache-size", 256, "Megabytes of session logs to keep parsed in memory (0 disables the cache)")
+	return func(args []string) error {
+		if len(args) > 0 {
+			fs.Usage()
+			return fmt.Errorf("unexpected argument %q", args[0])
+		}
+		srv, err := newServer(*logDir, *dataDir, *timezone)
+		if err != nil {
+			return err
+		}
+		if *cacheSize > 0 {
+			srv.Conversations = logparser.NewConversationCache(*cacheSize << 20)
+		}
+
+		addr := net.JoinHostPort(*host, fmt.Sprint(*port))
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return listenError(err, *host, *port)
+		}
+		printStartupInfo(addr, *port, *logDir, *dataDir)
+
+		hs := &http.Server{
+			Handler:           srv.Handler(),
+			ReadHeaderTimeout: *readHeaderTimeout,
+			ReadTimeout:       *readTimeout,
+			WriteTimeout:      *writeTimeout,
+			IdleTimeout:       *idleTimeout,
+			MaxHeaderBytes:    *maxHeaderBytes,
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		slog.Info("starting server", "addr", addr, "log-dir", *logDir)
+		err = serve(ctx, ln, hs, *shutdownTimeout)
+
+		// Save once requests have stopped, before the process exits.
+		if err := srv.Index.Save(); err != nil {
+			slog.Warn("failed to save session index", "error", err)
+		}
+		return err
+	}
+}
+
+// serve runs hs on ln until ctx is done, then shuts it down gracefully: it
+// stops accepting connections and waits up to timeout for in-flight
+// requests to complete before closing the remaining connections.
+func serve(ctx context.Context, ln net.Listener, hs *http.Server, timeout time.Duration) error {
+	errc := make(chan error, 1)
+	go func() { errc <- hs.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	slog.Info("shutting down", "timeout", timeout)
+	sctx, scancel := context.WithTimeout(context.Background(), timeout)
+	defer scancel()
+	if err := hs.Shutdown(sctx); err != nil {
+		hs.Close()
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("server stopped")
+	return nil
+}
+
+// listenError explains the usual reasons the server cannot listen.
+func listenError(err error, host string, port int) error {
+	switch {
+	case errors.Is(err, syscall.EADDRINUSE):
+		return fmt.Errorf("port %d is already in use on %s; another claude-code-share may be running. Stop it or choose another port with --port or CCS_PORT", port, host)
+	case errors.Is(err, syscall.EACCES):
+		return fmt.Errorf("no permission to listen on port %d; ports below 1024 need elevated privileges, choose a higher one with --port", port)
+	case errors.Is(err, syscall.EADDRNOTAVAIL):
+		return fmt.Errorf("%s is not an address of this machine; check --host", host)
+	}
+	return err
+}
+
+// newServer opens the data dir and session index and creates a server
+// showing times in the named time zone.
+func newServer(logDir, dataDir, timezone string) (*server.Server, error) {
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+	st, err := store.Open(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("open data dir: %w", err)
+	}
+	ix, err := openIndex(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	srv := server.New(logDir, st)
+	srv.Location = loc
+	srv.Index = ix
+	return srv, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/nhosoya/claude-code-share/internal/logparser"
+	"github.com/nhosoya/claude-code-share/internal/term"
+)
+
+// usageStats are the totals printed by the stats command.
+type usageStats struct {
+	Projects int            `json:"projects"`
+	Sessions int            `json:"sessions"`
+	Messages int            `json:"messages"`
+	Tokens   int            `json:"tokens"`
+	Cost     float64        `json:"cost"`
+	Models   map[string]int `json:"models"` // Sessions that used each model
+	Top      []projectUsage `json:"top"`    // Most expensive projects first
+}
+
+type projectUsage struct {
+	Slug     string  `json:"slug"`
+	Path     string  `json:"path"`
+	Sessions int     `json:"sessions"`
+	Tokens   int     `json:"tokens"`
+	Cost     float64 `json:"cost"`
+}
+
+// setupStats defines the stats command, which totals usage across all
+// sessions. It reads summaries from the session index.
+func setupStats(fs *flag.FlagSet) func(args []string) error {
+	dataDir := fs.String("data-dir", defaultDataDir(), "Path to store comments and other shared data")
+	since := fs.String("since", "", "Only count sessions active on or after this date (YYYY-MM-DD)")
+	top := fs.Int("top", 10, "Show this many of the most expensive projects")
+	c := addCLIFlags(fs)
+	return func(args []string) error {
+		var from time.Time
+		if *since != "" {
+			var err error
+			if from, err = time.ParseInLocation("2006-01-02", *since, time.Local); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+		}
+		ix, err := openIndex(*dataDir)
+		if err != nil {
+			return err
+		}
+		stats, err := collectStats(ix, c.logDir, from)
+		if err != nil {
+			return err
+		}
+		if err := ix.Save(); err != nil {
+			return err
+		}
+		if len(stats.Top) > *top {
+			stats.Top = stats.Top[:*top]
+		}
+
+		out, st := c.output()
+		defer out.Close()
+		if c.json {
+			return writeJSON(out, stats)
+		}
+		fmt.Fprintf(out, "%s  %d projects · %d sessions · %d messages\n", st.Style("Total", term.Bold), stats.Projects, stats.Sessions, stats.Messages)
+		fmt.Fprintf(out, "%s  %d tokens · $%.2f\n", st.Style("Usage", term.Bold), stats.Tokens, stats.Cost)
+
+		models := make([]string, 0, len(stats.Models))
+		for m := range stats.Models {
+			models = append(models, m)
+		}
+		sort.Slice(models, func(i, j int) bool {
+			if stats.Models[models[i]] != stats.Models[models[j]] {
+				return stats.Models[models[i]] > stats.Models[models[j]]
+			}
+			return models[i] < models[j]
+		})
+		if len(models) > 0 {
+			fmt.Fprintf(out, "\n%s\n", st.Style("Models", term.Bold))
+			for _, m := range models {
+				n := stats.Models[m]
+				fmt.Fprintf(out, "  %-32s %s\n", m, st.Style(fmt.Sprintf("%d session%s", n, plural(n)), term.Faint))
+			}
+		}
+		if len(stats.Top) > 0 {
+			fmt.Fprintf(out, "\n%s\n", st.Style("Top projects", term.Bold))
+			for _, p := range stats.Top {
+				fmt.Fprintf(out, "  %8s  %s  %s\n", fmt.Sprintf("$%.2f", p.Cost), p.Path,
+					st.Style(fmt.Sprintf("%d session%s · %d tokens", p.Sessions, plural(p.Sessions), p.Tokens), term.Faint))
+			}
+		}
+		return nil
+	}
+}
+
+// collectStats totals the sessions active since from; a zero from counts
+// every session.
+func collectStats(ix *logparser.SessionIndex, logDir string, from time.Time) (usageStats, error) {
+	stats := usageStats{Models: make(map[string]int), Top: []projectUsage{}}
+	projects, err := logparser.ListProjects(logDir)
+	if err != nil {
+		return stats, err
+	}
+	for _, p := range projects {
+		sessions, err := ix.ListSessions(logDir, p.Slug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", p.Slug, err)
+			continue
+		}
+		pu := projectUsage{Slug: p.Slug, Path: p.Path}
+		for _, s := range sessions {
+			if s.Timestamp.Before(from) {
+				continue
+			}
+			pu.Sessions++
+			pu.Tokens += s.Tokens()
+			pu.Cost += s.Cost
+			stats.Messages += s.MessageCount
+			models := s.Models
+			if len(models) == 0 && s.Model != "" {
+				models = []string{s.Model}
+			}
+			for _, m := range models {
+				stats.Models[m]++
+			}
+		}
+		if pu.Sessions == 0 {
+			continue
+		}
+		stats.Projects++
+		stats.Sessions += pu.Sessions
+		stats.Tokens += pu.Tokens
+		stats.Cost += pu.Cost
+		stats.Top = append(stats.Top, pu)
+	}
+	sort.SliceStable(stats.Top, func(i, j int) bool { return stats.Top[i].Cost > stats.Top[j].Cost })
+	return stats, nil
+}